@@ -0,0 +1,21 @@
+package main
+
+import "github.com/jason0x43/alfred-weather/forecast"
+
+// severityIcons are the icons for each alert severity, from grey for unknown
+// through yellow, orange and red to purple for extreme
+var severityIcons = map[forecast.Severity]string{
+	forecast.SeverityUnknown:  "alert_unknown.png",
+	forecast.SeverityAdvisory: "alert_advisory.png",
+	forecast.SeverityWatch:    "alert_watch.png",
+	forecast.SeverityWarning:  "alert.png",
+	forecast.SeverityExtreme:  "alert_extreme.png",
+}
+
+// severityIcon returns the icon file for an alert severity
+func severityIcon(s forecast.Severity) string {
+	if icon, ok := severityIcons[s]; ok {
+		return icon
+	}
+	return "alert_unknown.png"
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/go-alfred"
+)
+
+// the maximum number of characters shown in each alert detail item
+const alertLineWidth = 80
+
+// AlertsCommand lists active weather alerts
+type AlertsCommand struct{}
+
+// About returns information about a command
+func (c AlertsCommand) About() alfred.CommandDef {
+	return alfred.CommandDef{
+		Keyword:     "alerts",
+		Description: "Show active weather alerts for the default and saved locations",
+		IsEnabled:   true,
+	}
+}
+
+// Items returns the items for the command
+func (c AlertsCommand) Items(arg, data string) (items []alfred.Item, err error) {
+	dlog.Printf("Running AlertsCommand")
+
+	var cfg alertsCfg
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+			dlog.Printf("Invalid alerts config")
+		}
+	}
+
+	if err = validateConfig(); err != nil {
+		return []alfred.Item{errorItem(err)}, nil
+	}
+
+	now := clock.Now()
+
+	if cfg.Location != "" {
+		return showAlert(cfg, now)
+	}
+
+	for _, loc := range allLocations() {
+		var weather forecast.Weather
+		if weather, err = getForecast(loc, now); err != nil {
+			item := errorItem(err)
+			item.Title = loc.ShortName + ": " + item.Title
+			items = append(items, item)
+			err = nil
+			continue
+		}
+		items = append(items, alertListItems(&config, loc, weather, arg, now)...)
+	}
+
+	if len(items) == 0 {
+		items = append(items, alfred.Item{
+			Title:    tr("No active alerts"),
+			Subtitle: tr("Checked %d location(s)", len(allLocations())),
+		})
+	}
+
+	return
+}
+
+// Do runs the command
+func (c AlertsCommand) Do(data string) (out string, err error) {
+	var cfg alertsCfg
+
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+			dlog.Printf("Error unmarshaling tag data: %v", err)
+		}
+	}
+
+	if cfg.ToCopy != "" {
+		cmd := exec.Command("pbcopy")
+		cmd.Stdin = strings.NewReader(cfg.ToCopy)
+		if err = cmd.Run(); err != nil {
+			return
+		}
+		return tr("Copied alert text"), nil
+	}
+
+	if cfg.ToShow != "" {
+		// The workflow routes output with this prefix to Large Type
+		return "-largetype " + cfg.ToShow, nil
+	}
+
+	return
+}
+
+// alertListItems returns items for a location's alerts that are active at a
+// given time and match a query. Text is formatted according to c.
+func alertListItems(c *configStruct, loc forecast.Location, weather forecast.Weather, query string, now time.Time) (items []alfred.Item) {
+	for _, a := range weather.Alerts {
+		if !a.Expires.After(now) || !alfred.FuzzyMatches(a.Title+" "+loc.Name, query) {
+			continue
+		}
+
+		item := alfred.Item{
+			Title:    a.Title,
+			Subtitle: fmt.Sprintf("%s  ·  %s  ·  %s", loc.ShortName, c.tr(a.Severity.String()), c.tr("Until %s", a.Expires.Format(c.TimeFormat+", "+c.DateFormat))),
+			Icon:     severityIcon(a.Severity),
+			Arg: &alfred.ItemArg{
+				Keyword: "alerts",
+				Data:    alfred.Stringify(&alertsCfg{Location: loc.Name, Alert: alertKey(loc, a)}),
+			},
+		}
+		addAlertTextMods(c, &item, a, a.Title)
+		items = append(items, item)
+	}
+	return
+}
+
+// showAlert returns items showing the full text of the alert chosen in the
+// alert list
+func showAlert(cfg alertsCfg, now time.Time) (items []alfred.Item, err error) {
+	var loc *forecast.Location
+	for _, l := range allLocations() {
+		if l.Name == cfg.Location {
+			l := l
+			loc = &l
+			break
+		}
+	}
+	if loc == nil {
+		return items, fmt.Errorf("Unknown location %s", cfg.Location)
+	}
+
+	var weather forecast.Weather
+	if weather, err = getForecast(*loc, now); err != nil {
+		return
+	}
+	return alertDetailItems(&config, *loc, weather, cfg.Alert), nil
+}
+
+// alertDetailItems returns items showing the full text of the alert with a
+// given key. The alert is found by its key rather than its position because
+// the list of alerts may have changed since it was shown. Text is formatted
+// according to c.
+func alertDetailItems(c *configStruct, loc forecast.Location, weather forecast.Weather, key string) (items []alfred.Item) {
+	var a *forecast.Alert
+	for i := range weather.Alerts {
+		if alertKey(loc, weather.Alerts[i]) == key {
+			a = &weather.Alerts[i]
+			break
+		}
+	}
+
+	if a == nil {
+		items = append(items, alfred.Item{
+			Title:    c.tr("No active alerts"),
+			Subtitle: loc.Name,
+			Arg: &alfred.ItemArg{
+				Keyword: "alerts",
+			},
+		})
+		return
+	}
+
+	subtitle := []string{c.tr(a.Severity.String())}
+	if a.Sender != "" {
+		subtitle = append(subtitle, a.Sender)
+	}
+	subtitle = append(subtitle, loc.ShortName)
+
+	heading := alfred.Item{
+		Title:    a.Title,
+		Subtitle: strings.Join(subtitle, "  ·  "),
+		Icon:     severityIcon(a.Severity),
+		Arg: &alfred.ItemArg{
+			Keyword: "alerts",
+		},
+	}
+	addAlertTextMods(c, &heading, *a, a.Title)
+	items = append(items, heading)
+
+	period := c.tr("Until %s", a.Expires.Format(c.TimeFormat+", "+c.DateFormat))
+	if !a.Onset.IsZero() {
+		period = c.tr("From %s", a.Onset.Format(c.TimeFormat+", "+c.DateFormat)) + "  ·  " + period
+	}
+	items = append(items, alfred.Item{
+		Title:    period,
+		Subtitle: alfred.Line,
+	})
+
+	if len(a.Regions) > 0 {
+		regions := strings.Join(a.Regions, ", ")
+		item := alfred.Item{
+			Title:    regions,
+			Subtitle: c.tr("Regions"),
+		}
+		addAlertTextMods(c, &item, *a, regions)
+		items = append(items, item)
+	}
+
+	for _, line := range splitAlertText(a.Description, alertLineWidth) {
+		item := alfred.Item{
+			Title: line,
+		}
+		addAlertTextMods(c, &item, *a, line)
+		items = append(items, item)
+	}
+
+	if a.URL != "" {
+		items = append(items, alfred.Item{
+			Title:    c.tr("Open this alert in a browser"),
+			Subtitle: a.URL,
+			Arg: &alfred.ItemArg{
+				Keyword: "daily",
+				Mode:    alfred.ModeDo,
+				Data:    alfred.Stringify(&dailyCfg{ToOpen: a.URL}),
+			},
+		})
+	}
+
+	return
+}
+
+// addAlertTextMods adds modifiers to copy an alert's full text or to show
+// part of it in Large Type
+func addAlertTextMods(c *configStruct, item *alfred.Item, a forecast.Alert, text string) {
+	item.AddMod(alfred.ModCmd, alfred.ItemMod{
+		Subtitle: c.tr("Copy the full alert text"),
+		Arg: &alfred.ItemArg{
+			Keyword: "alerts",
+			Mode:    alfred.ModeDo,
+			Data:    alfred.Stringify(&alertsCfg{ToCopy: alertText(a)}),
+		},
+	})
+	item.AddMod(alfred.ModAlt, alfred.ItemMod{
+		Subtitle: c.tr("Show in Large Type"),
+		Arg: &alfred.ItemArg{
+			Keyword: "alerts",
+			Mode:    alfred.ModeDo,
+			Data:    alfred.Stringify(&alertsCfg{ToShow: text}),
+		},
+	})
+}
+
+// alertText returns the full text of an alert
+func alertText(a forecast.Alert) string {
+	parts := []string{a.Title}
+	if len(a.Regions) > 0 {
+		parts = append(parts, strings.Join(a.Regions, ", "))
+	}
+	if a.Description != "" {
+		parts = append(parts, a.Description)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// splitAlertText splits alert text into readable lines. Hard-wrapped lines
+// are joined, paragraphs and bulleted sections are kept separate, and long
+// paragraphs are broken at word boundaries.
+func splitAlertText(text string, width int) (lines []string) {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+
+	var paragraphs []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, " "))
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "* "), strings.HasPrefix(line, "- "):
+			flush()
+			current = append(current, line)
+		default:
+			current = append(current, line)
+		}
+	}
+	flush()
+
+	for _, p := range paragraphs {
+		var line string
+		for _, word := range strings.Fields(p) {
+			if line != "" && len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return
+}
+
+type alertsCfg struct {
+	Location string `json:",omitempty"`
+	Alert    string `json:",omitempty"`
+	ToCopy   string `json:",omitempty"`
+	ToShow   string `json:",omitempty"`
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// alertWeather returns a forecast with an expired alert and two active ones
+func alertWeather(day time.Time) (weather forecast.Weather) {
+	weather = testWeather()
+	weather.Alerts = []forecast.Alert{
+		{
+			Title:    "Frost Advisory",
+			Severity: forecast.SeverityAdvisory,
+			Onset:    day.Add(-12 * time.Hour),
+			Expires:  day.Add(8 * time.Hour),
+			Sender:   "DWD",
+		},
+		{
+			Title:    "Winter Storm Warning",
+			Severity: forecast.SeverityWarning,
+			Onset:    day.Add(18 * time.Hour),
+			Expires:  day.Add(36 * time.Hour),
+			Sender:   "DWD",
+			Regions:  []string{"Berlin", "Potsdam"},
+			Description: "Heavy snow expected. Total snow accumulations of 20 to 30 cm,\n" +
+				"with strong winds gusting as high as 80 km/h.\n\n" +
+				"* WHERE...Berlin and Potsdam.\n" +
+				"* WHEN...From 6 PM this evening to 12 AM Friday.",
+			URL: "https://example.com/alerts/storm",
+		},
+		{
+			Title:    "Flood Watch",
+			Severity: forecast.SeverityWatch,
+			Expires:  day.Add(30 * time.Hour),
+			Sender:   "DWD",
+		},
+	}
+	return
+}
+
+func TestAlertItems(t *testing.T) {
+	useOtherConfig(t)
+
+	loc := forecast.Location{Name: "Berlin, Germany", ShortName: "Berlin"}
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	now := day.Add(10 * time.Hour)
+	weather := alertWeather(day)
+
+	for _, c := range itemConfigs {
+		name := "alerts_" + c.name + ".golden"
+		t.Run(name, func(t *testing.T) {
+			checkGoldenItems(t, name, alertListItems(&c.config, loc, weather, "", now))
+		})
+
+		name = "alert_detail_" + c.name + ".golden"
+		t.Run(name, func(t *testing.T) {
+			key := alertKey(loc, weather.Alerts[1])
+			checkGoldenItems(t, name, alertDetailItems(&c.config, loc, weather, key))
+		})
+	}
+}
+
+func TestAlertDetailAfterRefresh(t *testing.T) {
+	loc := forecast.Location{Name: "Berlin, Germany", ShortName: "Berlin"}
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	c := &itemConfigs[0].config
+
+	// The list was shown, then the forecast was refreshed and the alerts
+	// changed order and one was dropped
+	shown := alertWeather(day)
+	key := alertKey(loc, shown.Alerts[2])
+
+	refreshed := alertWeather(day)
+	refreshed.Alerts = []forecast.Alert{refreshed.Alerts[2], refreshed.Alerts[1]}
+
+	items := alertDetailItems(c, loc, refreshed, key)
+	if items[0].Title != "Flood Watch" {
+		t.Errorf("title = %q, want the chosen alert", items[0].Title)
+	}
+
+	refreshed.Alerts = refreshed.Alerts[1:]
+	items = alertDetailItems(c, loc, refreshed, key)
+	if len(items) != 1 || items[0].Title != "No active alerts" {
+		t.Errorf("items = %v, want No active alerts once the alert is gone", items)
+	}
+}
+
+func TestAlertListQuery(t *testing.T) {
+	loc := forecast.Location{Name: "Berlin, Germany", ShortName: "Berlin"}
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+
+	items := alertListItems(&itemConfigs[0].config, loc, alertWeather(day), "flood", day.Add(10*time.Hour))
+	if len(items) != 1 || items[0].Title != "Flood Watch" {
+		t.Errorf("items = %v, want only the Flood Watch", items)
+	}
+}
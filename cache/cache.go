@@ -0,0 +1,111 @@
+// Package cache stores recently fetched forecasts in a JSON file.
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/alfred-weather/sharedfile"
+)
+
+// MaxAge is how long a cached forecast remains fresh
+const MaxAge = 5 * time.Minute
+
+// Entry is the weather for a location at a given time
+type Entry struct {
+	Weather forecast.Weather
+	Time    time.Time
+	Service string
+}
+
+// Expired indicates whether an entry should be refreshed at a given time. An
+// entry expires after MaxAge, at midnight, and when it came from a different
+// service.
+func (e Entry) Expired(now time.Time, service string) bool {
+	y1, m1, d1 := now.Date()
+	y2, m2, d2 := e.Time.In(now.Location()).Date()
+	return now.Sub(e.Time) >= MaxAge || now.Before(e.Time) ||
+		y1 != y2 || m1 != m2 || d1 != d2 ||
+		e.Service != service
+}
+
+// Store is a set of cached forecasts, keyed by location name, that is saved
+// to a file. It's safe for concurrent use, and several processes can share a
+// file.
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]Entry
+
+	// cleared indicates that the store was cleared since it was last saved
+	cleared bool
+}
+
+// Open loads a store from a file. A missing file gives an empty store. If the
+// file can't be read, an empty store is returned along with the error.
+func Open(path string) (s *Store, err error) {
+	s = &Store{path: path, entries: map[string]Entry{}}
+
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	if err = json.Unmarshal(data, &s.entries); err != nil || s.entries == nil {
+		s.entries = map[string]Entry{}
+	}
+	return
+}
+
+// Get returns the entry for a key
+func (s *Store) Get(key string) (e Entry, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok = s.entries[key]
+	return
+}
+
+// Put sets the entry for a key
+func (s *Store) Put(key string, e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = e
+}
+
+// Clear removes all entries
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = map[string]Entry{}
+	s.cleared = true
+}
+
+// Save writes the store to its file. Newer forecasts saved by other processes
+// since the file was read are kept, unless the store has been cleared.
+func (s *Store) Save() (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return sharedfile.Update(s.path, func(data []byte) ([]byte, error) {
+		if data != nil && !s.cleared {
+			var saved map[string]Entry
+			if err := json.Unmarshal(data, &saved); err == nil {
+				for k, e := range saved {
+					if current, ok := s.entries[k]; !ok || e.Time.After(current.Time) {
+						s.entries[k] = e
+					}
+				}
+			}
+		}
+		s.cleared = false
+
+		return json.MarshalIndent(s.entries, "", "\t")
+	})
+}
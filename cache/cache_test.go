@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+var now = time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+func entry(summary string, t time.Time) Entry {
+	return Entry{Weather: forecast.Weather{Current: forecast.Conditions{Summary: summary}}, Time: t, Service: "darksky"}
+}
+
+func TestSaveMergesOtherProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	watcher, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher.Put("Berlin", entry("old", now))
+	watcher.Put("Paris", entry("watcher", now))
+
+	// Another process saves a newer forecast for one location and one for a
+	// location the watcher doesn't know
+	other, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Put("Berlin", entry("new", now.Add(time.Minute)))
+	other.Put("Paris", entry("older", now.Add(-time.Minute)))
+	other.Put("Rome", entry("other", now))
+	if err := other.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := watcher.Save(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"Berlin": "new", "Paris": "watcher", "Rome": "other"} {
+		if e, _ := saved.Get(key); e.Weather.Current.Summary != want {
+			t.Errorf("%s = %q, want %q", key, e.Weather.Current.Summary, want)
+		}
+	}
+}
+
+func TestSaveCleared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	other, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Put("Berlin", entry("new", now))
+	if err := other.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Clear()
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved.Get("Berlin"); ok {
+		t.Error("cleared entry was saved")
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/go-alfred"
+)
+
+// ANSI escape sequences used for terminal output
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiDim    = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+	ansiCyan   = "\033[36m"
+)
+
+// terminal writes colored output to a stream
+type terminal struct {
+	out   io.Writer
+	color bool
+}
+
+// paint wraps text in an ANSI style if color is enabled
+func (t terminal) paint(style, text string) string {
+	if !t.color || style == "" {
+		return text
+	}
+	return style + text + ansiReset
+}
+
+// printf writes formatted text to the terminal
+func (t terminal) printf(format string, args ...interface{}) {
+	fmt.Fprintf(t.out, format, args...)
+}
+
+// cell is a table cell with an optional style
+type cell struct {
+	text  string
+	style string
+}
+
+// table prints rows of cells with aligned columns. Widths are computed from
+// the unstyled text so that escape sequences don't affect alignment.
+func (t terminal) table(header []string, rows [][]cell) {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, c := range row {
+			if n := utf8.RuneCountInString(c.text); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var line []string
+	for i, h := range header {
+		line = append(line, t.paint(ansiBold, pad(h, widths[i])))
+	}
+	t.printf("%s\n", strings.TrimRight(strings.Join(line, "  "), " "))
+
+	for _, row := range rows {
+		line = nil
+		for i, c := range row {
+			line = append(line, t.paint(c.style, pad(c.text, widths[i])))
+		}
+		t.printf("%s\n", strings.TrimRight(strings.Join(line, "  "), " "))
+	}
+}
+
+func pad(text string, width int) string {
+	return text + strings.Repeat(" ", width-utf8.RuneCountInString(text))
+}
+
+// tempStyle returns a color for a temperature
+func tempStyle(t forecast.Temperature) string {
+	switch {
+	case t <= 0:
+		return ansiBlue
+	case t >= 30:
+		return ansiRed
+	case t >= 25:
+		return ansiYellow
+	}
+	return ""
+}
+
+// runCLI shows a forecast in a terminal. It returns an exit status.
+func runCLI(args []string) int {
+	defaultData, defaultCache := standaloneDirs()
+
+	flags := flag.NewFlagSet("cli", flag.ContinueOnError)
+	dataDir := flags.String("data", defaultData, "directory holding config.json")
+	cacheDirFlag := flags.String("cache", defaultCache, "directory holding cache.json")
+	noColor := flags.Bool("no-color", false, "disable colored output")
+	verbose := flags.Bool("v", false, "log debug messages to stderr")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: alfred-weather cli [flags] daily|hourly|current|alerts|json|ics [query]\n")
+		fmt.Fprintf(flags.Output(), "       alfred-weather cli [flags] set <option> <value>\n\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if !*verbose {
+		dlog.SetOutput(io.Discard)
+	}
+
+	if err := initStandaloneConfig(*dataDir, *cacheDirFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	term := terminal{out: os.Stdout, color: !*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)}
+
+	view := "daily"
+	if flags.NArg() > 0 {
+		view = flags.Arg(0)
+	}
+	var query string
+	if flags.NArg() > 1 {
+		query = strings.Join(flags.Args()[1:], " ")
+	}
+
+	var err error
+	switch view {
+	case "daily", "hourly", "current", "alerts":
+		err = printForecast(term, view, query)
+	case "json":
+		err = printJSONForecast(query)
+	case "ics":
+		err = printICalendar(query)
+	case "set":
+		err = setCLIOption(flags.Args()[1:])
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// standaloneDirs returns the default data and cache directories used when
+// running outside of Alfred
+func standaloneDirs() (dataDir, cacheDir string) {
+	configDir, _ := os.UserConfigDir()
+	userCacheDir, _ := os.UserCacheDir()
+	return path.Join(configDir, "alfred-weather"), path.Join(userCacheDir, "alfred-weather")
+}
+
+// initStandaloneConfig creates the data and cache directories if necessary
+// and loads the config from them
+func initStandaloneConfig(dataDir, cacheDir string) error {
+	for _, dir := range []string{dataDir, cacheDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	initConfig(dataDir, cacheDir)
+	return nil
+}
+
+// isTerminal indicates whether a file is a character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setCLIOption updates an option in the config file
+func setCLIOption(args []string) (err error) {
+	if len(args) < 2 {
+		return fmt.Errorf("Usage: set <option> <value>")
+	}
+
+	name := args[0]
+	value := strings.Join(args[1:], " ")
+
+	s := findSetting(name)
+	if s == nil {
+		return fmt.Errorf("Unknown option %q", name)
+	}
+
+	var note string
+	if value, note, err = s.check(value); err != nil {
+		return fmt.Errorf("Not saving %s: %v", name, err)
+	}
+	if err = s.set(&config, value); err != nil {
+		return
+	}
+	config.Units = config.units().preset()
+
+	if note == "" {
+		note = s.displayValue(&config)
+	}
+	fmt.Printf("%s: %s\n", name, note)
+
+	return alfred.SaveJSON(configFile, &config)
+}
+
+// printJSONForecast prints the forecast for a location as a JSON document
+func printJSONForecast(query string) (err error) {
+	var weather forecast.Weather
+	var loc forecast.Location
+	now := clock.Now()
+	if loc, weather, err = getWeather(query, now); err != nil {
+		return
+	}
+	return writeJSON(os.Stdout, newJSONForecast(loc, weather, now))
+}
+
+// printICalendar prints the forecast for a location as an iCalendar file
+func printICalendar(query string) (err error) {
+	var weather forecast.Weather
+	var loc forecast.Location
+	now := clock.Now()
+	if loc, weather, err = getWeather(query, now); err != nil {
+		return
+	}
+	return writeICalendar(os.Stdout, loc, weather, now)
+}
+
+// printForecast prints one of the forecast views to a terminal
+func printForecast(term terminal, view, query string) (err error) {
+	now := clock.Now()
+	query, when := parseTimeQuery(query, now)
+
+	var weather forecast.Weather
+	var loc forecast.Location
+	if loc, weather, err = getWeather(query, now); err != nil {
+		return
+	}
+
+	term.printf("%s\n", term.paint(ansiBold, tr("Weather for %s", loc.Name)))
+
+	for _, a := range weather.Alerts {
+		if a.Expires.After(now) {
+			term.printf("%s %s\n", term.paint(ansiRed+ansiBold, "! "+a.Title),
+				term.paint(ansiDim, tr("Until %s", a.Expires.Format(config.TimeFormat+", "+config.DateFormat))))
+			if view == "alerts" {
+				for _, line := range splitAlertText(a.Description, alertLineWidth) {
+					term.printf("  %s\n", line)
+				}
+			}
+		}
+	}
+	for _, m := range evaluateRules(loadRules(), &weather, now) {
+		term.printf("%s\n", term.paint(ansiYellow, tr("Rule matched: %s", m.Rule)))
+	}
+
+	switch view {
+	case "current":
+		c := weather.Current
+		parts := []string{
+			term.paint(tempStyle(c.Temp), formatTemperature(c.Temp)),
+			fmt.Sprintf("(%s)", formatTemperature(c.ApparentTemp)),
+		}
+		if c.WindSpeed > 0 {
+			parts = append(parts, "⚑ "+formatSpeed(c.WindSpeed))
+		}
+		if c.Pressure > 0 {
+			parts = append(parts, "◎ "+formatPressure(c.Pressure))
+		}
+		term.printf("%s  %s\n", tr("Currently: %s", c.Summary), strings.Join(parts, "  "))
+
+	case "daily":
+		var rows [][]cell
+		for _, d := range weather.Daily {
+			if when.IsSet() && d.Date.Format("2006-01-02") < when.Time.Format("2006-01-02") {
+				continue
+			}
+			precip := "-"
+			if d.Precip != -1 {
+				precip = fmt.Sprintf("%d%%", d.Precip)
+			}
+			rows = append(rows, []cell{
+				{text: weekday(d.Date), style: ansiCyan},
+				{text: d.Summary},
+				{text: formatTemperature(d.LowTemp), style: tempStyle(d.LowTemp)},
+				{text: formatTemperature(d.HighTemp), style: tempStyle(d.HighTemp)},
+				{text: precip},
+				{text: d.Sunrise.Format(config.TimeFormat), style: ansiDim},
+				{text: d.Sunset.Format(config.TimeFormat), style: ansiDim},
+			})
+		}
+		term.table([]string{tr("Day"), tr("Conditions"), tr("Low"), tr("High"), tr("Precip"), tr("Sunrise"), tr("Sunset")}, rows)
+
+	case "hourly":
+		start := time.Time{}
+		if when.IsSet() {
+			start = when.Time.Truncate(time.Hour)
+		}
+		var rows [][]cell
+		for _, h := range weather.Hourly {
+			if h.Time.Before(start) {
+				continue
+			}
+			precip := "-"
+			if h.Precip != -1 {
+				precip = fmt.Sprintf("%d%%", h.Precip)
+			}
+			wind := "-"
+			if h.WindSpeed > 0 {
+				wind = formatSpeed(h.WindSpeed)
+			}
+			rows = append(rows, []cell{
+				{text: shortWeekday(h.Time) + " " + h.Time.Format(config.TimeFormat), style: ansiCyan},
+				{text: h.Summary},
+				{text: formatTemperature(h.Temp), style: tempStyle(h.Temp)},
+				{text: formatTemperature(h.ApparentTemp), style: tempStyle(h.ApparentTemp)},
+				{text: precip},
+				{text: wind},
+			})
+		}
+		term.table([]string{tr("Time"), tr("Conditions"), tr("Temp"), tr("Feels"), tr("Precip"), tr("Wind")}, rows)
+	}
+
+	return
+}
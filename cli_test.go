@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+func TestPrintForecast(t *testing.T) {
+	for _, view := range []string{"daily", "hourly"} {
+		name := "cli_" + view + "_de.golden"
+		t.Run(name, func(t *testing.T) {
+			useFakeServices(t)
+			useClock(t, testNow)
+			config.Language = "de"
+			config.TimeFormat = "15:04"
+			config.DateFormat = "2.1."
+			config.TemperatureUnit = forecast.Celsius
+			config.WindUnit = forecast.KilometersPerHour
+
+			var buf bytes.Buffer
+			if err := printForecast(terminal{out: &buf}, view, ""); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, name, buf.Bytes())
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "time"
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is a Clock that reads the system time
+type systemClock struct{}
+
+// Now returns the current system time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// fixedClock is a Clock that is stopped at a given time
+type fixedClock time.Time
+
+// Now returns the clock's time
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// clock is the source of the current time. Commands and request handlers read
+// it once and pass the time down to anything that depends on it, such as cache
+// expiry and whether it's currently night. It can be replaced to run at a
+// different time.
+var clock Clock = systemClock{}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jason0x43/alfred-weather/forecast"
 	"github.com/jason0x43/go-alfred"
 )
 
@@ -26,20 +27,30 @@ func (c DailyCommand) About() alfred.CommandDef {
 func (c DailyCommand) Items(arg, data string) (items []alfred.Item, err error) {
 	dlog.Printf("Running DailyCommand")
 
-	var weather Weather
-	var loc Location
-	if loc, weather, err = getWeather(arg); err != nil {
-		return
+	now := clock.Now()
+	query, when := parseTimeQuery(arg, now)
+
+	var weather forecast.Weather
+	var loc forecast.Location
+	if loc, weather, err = getWeather(query, now); err != nil {
+		return []alfred.Item{errorItem(err)}, nil
 	}
 
+	return dailyItems(&config, loc, weather, loadRules(), when, now), nil
+}
+
+// dailyItems returns the items for a location's daily forecast at a given
+// time, starting from the date of a time query if one is set. Text is
+// formatted according to c.
+func dailyItems(c *configStruct, loc forecast.Location, weather forecast.Weather, rules []rule, when timeQuery, now time.Time) (items []alfred.Item) {
 	heading := alfred.Item{
-		Title:    "Weather for " + loc.Name,
+		Title:    c.tr("Weather for %s", loc.Name),
 		Subtitle: alfred.Line,
 	}
 
 	if weather.URL != "" {
 		heading.AddMod(alfred.ModCmd, alfred.ItemMod{
-			Subtitle: "Open this forecast in a browser",
+			Subtitle: c.tr("Open this forecast in a browser"),
 			Arg: &alfred.ItemArg{
 				Keyword: "daily",
 				Mode:    alfred.ModeDo,
@@ -50,17 +61,23 @@ func (c DailyCommand) Items(arg, data string) (items []alfred.Item, err error) {
 
 	items = append(items, heading)
 
-	deg := "F"
-	if config.Units == unitsMetric {
-		deg = "C"
-	}
+	items = append(items, alertItems(c, &weather, now)...)
+	items = append(items, ruleItems(c, rules, &weather, now)...)
 
-	addAlertItems(&weather, &items)
+	current := []string{
+		fmt.Sprintf("%s (%s)", c.formatTemperature(weather.Current.Temp), c.formatTemperature(weather.Current.ApparentTemp)),
+	}
+	if weather.Current.WindSpeed > 0 {
+		current = append(current, fmt.Sprintf("⚑ %s", c.formatSpeed(weather.Current.WindSpeed)))
+	}
+	if weather.Current.Pressure > 0 {
+		current = append(current, fmt.Sprintf("◎ %s", c.formatPressure(weather.Current.Pressure)))
+	}
 
 	items = append(items, alfred.Item{
-		Title:    "Currently: " + weather.Current.Summary,
-		Subtitle: fmt.Sprintf("%d°%s (%d°%s)", weather.Current.Temp.Int64(), deg, weather.Current.ApparentTemp.Int64(), deg),
-		Icon:     getIconFile(weather.Current.Icon),
+		Title:    c.tr("Currently: %s", weather.Current.Summary),
+		Subtitle: strings.Join(current, "    "),
+		Icon:     c.iconFile(weather.Current.Icon),
 		Arg: &alfred.ItemArg{
 			Keyword: "hourly",
 			Data:    alfred.Stringify(&hourlyConfig{Start: &weather.Current.Time}),
@@ -68,26 +85,29 @@ func (c DailyCommand) Items(arg, data string) (items []alfred.Item, err error) {
 	})
 
 	for _, entry := range weather.Daily {
+		if when.IsSet() && entry.Date.Format("2006-01-02") < when.Time.Format("2006-01-02") {
+			continue
+		}
+
 		var date string
-		now := time.Now()
 		conditions := entry.Summary
 		icon := entry.Icon
 
-		if entry.Date.Format("1/2/2006") == now.Format("1/2/2006") {
+		if sameDay(entry.Date, now) {
 			if weather.IsAtNight(now) {
-				date = "Tonight"
+				date = c.tr("Tonight")
 				icon = "nt_" + icon
 				conditions = strings.Replace(conditions, " day", " night", -1)
 			} else {
-				date = "Today"
+				date = c.tr("Today")
 			}
 		} else {
-			date = entry.Date.Format("Monday")
+			date = c.weekday(entry.Date)
 		}
 
 		parts := []string{
-			fmt.Sprintf("↓ %d°%s", entry.LowTemp.Int64(), deg),
-			fmt.Sprintf("↑ %d°%s", entry.HighTemp.Int64(), deg),
+			fmt.Sprintf("↓ %s", c.formatTemperature(entry.LowTemp)),
+			fmt.Sprintf("↑ %s", c.formatTemperature(entry.HighTemp)),
 		}
 
 		dlog.Printf("precip: %d\n", entry.Precip)
@@ -96,22 +116,29 @@ func (c DailyCommand) Items(arg, data string) (items []alfred.Item, err error) {
 			parts = append(parts, fmt.Sprintf("☂ %d%%", entry.Precip))
 		}
 
-		parts = append(
-			parts,
-			fmt.Sprintf("☼ %s", entry.Sunrise.Format(config.TimeFormat)),
-			fmt.Sprintf("☾ %s", entry.Sunset.Format(config.TimeFormat)),
-		)
+		// Services don't report sun times during polar day or night
+		if entry.Sunrise.Unix() > 0 && entry.Sunset.Unix() > 0 {
+			parts = append(
+				parts,
+				fmt.Sprintf("☼ %s", entry.Sunrise.Format(c.TimeFormat)),
+				fmt.Sprintf("☾ %s", entry.Sunset.Format(c.TimeFormat)),
+			)
+		}
 
 		item := alfred.Item{
 			Title:    date + ": " + conditions,
 			Subtitle: strings.Join(parts, "    "),
-			Icon:     getIconFile(icon),
+			Icon:     c.iconFile(icon),
 		}
 
 		if hasHourly(weather, entry.Date) {
+			start := entry.Sunrise
+			if when.HasTime && entry.Date.Format("2006-01-02") == when.Time.Format("2006-01-02") {
+				start = when.Time.Truncate(time.Hour)
+			}
 			item.Arg = &alfred.ItemArg{
 				Keyword: "hourly",
-				Data:    alfred.Stringify(&hourlyConfig{Start: &entry.Sunrise}),
+				Data:    alfred.Stringify(&hourlyConfig{Start: &start}),
 			}
 		}
 
@@ -139,21 +166,30 @@ func (c DailyCommand) Do(data string) (out string, err error) {
 	return
 }
 
-func addAlertItems(weather *Weather, items *[]alfred.Item) {
-	now := time.Now()
-
+// alertItems returns items for the alerts that are active at a given time.
+// Text is formatted according to c.
+func alertItems(c *configStruct, weather *forecast.Weather, now time.Time) (items []alfred.Item) {
 	for _, alert := range weather.Alerts {
 		if alert.Expires.After(now) {
-			subtitle := fmt.Sprintf("Until %s", alert.Expires.Format(config.TimeFormat))
-			expireDate := alert.Expires.Format(config.DateFormat)
-			if expireDate != now.Format(config.DateFormat) {
-				subtitle += fmt.Sprintf(" on %s", expireDate)
+			subtitle := c.tr("Until %s", alert.Expires.Format(c.TimeFormat))
+			expireDate := alert.Expires.Format(c.DateFormat)
+			if expireDate != now.Format(c.DateFormat) {
+				subtitle += c.tr(" on %s", expireDate)
+			}
+
+			parts := []string{c.tr(alert.Severity.String())}
+			if alert.Onset.After(now) {
+				parts = append(parts, c.tr("From %s", alert.Onset.Format(c.TimeFormat+", "+c.DateFormat)))
+			}
+			parts = append(parts, subtitle)
+			if len(alert.Regions) > 0 {
+				parts = append(parts, strings.Join(alert.Regions, ", "))
 			}
 
 			item := alfred.Item{
-				Title:    alert.Description,
-				Subtitle: subtitle,
-				Icon:     "alert.png",
+				Title:    alert.Title,
+				Subtitle: strings.Join(parts, "  ·  "),
+				Icon:     severityIcon(alert.Severity),
 			}
 
 			if alert.URL != "" {
@@ -164,14 +200,14 @@ func addAlertItems(weather *Weather, items *[]alfred.Item) {
 				}
 			}
 
-			*items = append(*items, item)
+			items = append(items, item)
 		}
 	}
 
 	return
 }
 
-func hasHourly(weather Weather, date time.Time) bool {
+func hasHourly(weather forecast.Weather, date time.Time) bool {
 	target := date.Format("2006-01-02")
 	for i := range weather.Hourly {
 		if weather.Hourly[i].Time.Format("2006-01-02") == target {
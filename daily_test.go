@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// useClock stops the clock at a time until the test ends
+func useClock(t *testing.T, now time.Time) {
+	saved := clock
+	clock = fixedClock(now)
+	t.Cleanup(func() { clock = saved })
+}
+
+// clearDays returns a forecast of clear days starting on a date, with the
+// sun up from 6:00 to 20:00
+func clearDays(start time.Time, days int) (weather forecast.Weather) {
+	weather.Current = forecast.Conditions{Summary: "Clear", Icon: "clear", Temp: 10}
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i)
+		weather.Daily = append(weather.Daily, forecast.Daily{
+			Date:    date,
+			Summary: "Clear during the day",
+			Icon:    "clear",
+			Precip:  -1,
+			Sunrise: date.Add(6 * time.Hour),
+			Sunset:  date.Add(20 * time.Hour),
+		})
+	}
+	return
+}
+
+// dailyTitles runs the daily command at the clock's time and returns the
+// titles of the items after the heading
+func dailyTitles(t *testing.T) (titles []string) {
+	t.Helper()
+	items, err := DailyCommand{}.Items("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items[1:] {
+		titles = append(titles, item.Title)
+	}
+	return
+}
+
+func checkTitles(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("titles = %q, want %q", got, want)
+	}
+}
+
+func TestDailyMidnight(t *testing.T) {
+	provider := useFakeServices(t)
+	provider.weather = clearDays(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), 2)
+
+	useClock(t, time.Date(2020, 6, 1, 23, 58, 0, 0, time.UTC))
+	checkTitles(t, dailyTitles(t), []string{
+		"Currently: Clear",
+		"Tonight: Clear during the night",
+		"Tuesday: Clear during the day",
+	})
+
+	// A few minutes later it's a new day, so the cached forecast is refreshed
+	// even though it isn't old
+	useClock(t, time.Date(2020, 6, 2, 0, 1, 0, 0, time.UTC))
+	checkTitles(t, dailyTitles(t), []string{
+		"Currently: Clear",
+		"Monday: Clear during the day",
+		"Tonight: Clear during the night",
+	})
+	if len(provider.calls) != 2 {
+		t.Errorf("provider was called %d times, want 2", len(provider.calls))
+	}
+}
+
+func TestDailyDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		name          string
+		before, after time.Time
+	}{
+		// Clocks go forward from 2:00 to 3:00
+		{"spring", time.Date(2020, 3, 29, 1, 58, 0, 0, berlin), time.Date(2020, 3, 29, 3, 1, 0, 0, berlin)},
+		// Clocks go back from 3:00 to 2:00, so the later time reads earlier
+		{"autumn", time.Date(2020, 10, 25, 2, 58, 0, 0, berlin).Add(-time.Hour), time.Date(2020, 10, 25, 2, 1, 0, 0, berlin)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if d := test.after.Sub(test.before); d != 3*time.Minute {
+				t.Fatalf("times are %v apart, want 3m", d)
+			}
+
+			provider := useFakeServices(t)
+			y, m, d := test.before.Date()
+			day := time.Date(y, m, d, 0, 0, 0, 0, berlin)
+			provider.weather = clearDays(day.AddDate(0, 0, -1), 2)
+
+			want := []string{
+				"Currently: Clear",
+				weekday(day.AddDate(0, 0, -1)) + ": Clear during the day",
+				"Tonight: Clear during the night",
+			}
+
+			useClock(t, test.before)
+			checkTitles(t, dailyTitles(t), want)
+			useClock(t, test.after)
+			checkTitles(t, dailyTitles(t), want)
+
+			// The clock change doesn't make the cached forecast look stale
+			if len(provider.calls) != 1 {
+				t.Errorf("provider was called %d times, want 1", len(provider.calls))
+			}
+		})
+	}
+}
+
+func TestDailyPolar(t *testing.T) {
+	day := time.Date(2020, 6, 21, 0, 0, 0, 0, time.UTC)
+
+	// Services report no sunrise or sunset (a time of 0) when the sun doesn't
+	// rise or set, so night is told from the icons
+	tests := []struct {
+		name string
+		icon string
+		want []string
+	}{
+		{"midnight sun", "clear", []string{
+			"Currently: Clear",
+			"Today: Clear during the day",
+			"Monday: Clear during the day",
+		}},
+		{"polar night", "nt_clear", []string{
+			"Currently: Clear",
+			"Tonight: Clear during the night",
+			"Monday: Clear during the day",
+		}},
+	}
+
+	for _, test := range tests {
+		for _, hour := range []int{0, 12} {
+			t.Run(fmt.Sprintf("%s at %d:00", test.name, hour), func(t *testing.T) {
+				provider := useFakeServices(t)
+				weather := clearDays(day, 2)
+				weather.Current.Icon = test.icon
+				weather.Current.Time = day.Add(time.Duration(hour) * time.Hour)
+				for i := range weather.Daily {
+					weather.Daily[i].Sunrise = time.Unix(0, 0)
+					weather.Daily[i].Sunset = time.Unix(0, 0)
+				}
+				for i := 0; i < 48; i++ {
+					weather.Hourly = append(weather.Hourly, forecast.Hourly{
+						Time: day.Add(time.Duration(i) * time.Hour),
+						Icon: test.icon,
+					})
+				}
+				provider.weather = weather
+
+				useClock(t, day.Add(time.Duration(hour)*time.Hour))
+				items, err := DailyCommand{}.Items("", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var titles []string
+				for _, item := range items[1:] {
+					titles = append(titles, item.Title)
+					if strings.Contains(item.Subtitle, "☼") || strings.Contains(item.Subtitle, "☾") {
+						t.Errorf("subtitle %q shows sun times", item.Subtitle)
+					}
+				}
+				checkTitles(t, titles, test.want)
+			})
+		}
+	}
+}
+
+func TestDailyExpiringAlert(t *testing.T) {
+	provider := useFakeServices(t)
+	day := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	provider.weather = clearDays(day, 1)
+	provider.weather.Alerts = []forecast.Alert{{
+		Title:    "Heat Advisory",
+		Severity: forecast.SeverityAdvisory,
+		Onset:    day.Add(10 * time.Hour),
+		Expires:  day.Add(14 * time.Hour),
+	}}
+
+	tests := []struct {
+		now   time.Time
+		alert bool
+	}{
+		{day.Add(9 * time.Hour), true},
+		{day.Add(13*time.Hour + 59*time.Minute), true},
+		{day.Add(14 * time.Hour), false},
+	}
+
+	for _, test := range tests {
+		useClock(t, test.now)
+		titles := dailyTitles(t)
+		if got := titles[0] == "Heat Advisory"; got != test.alert {
+			t.Errorf("at %s, alert shown = %v, want %v", test.now.Format("15:04"), got, test.alert)
+		}
+	}
+}
+
+// itemConfigs are the configs that script filter items are checked with
+var itemConfigs = []struct {
+	name   string
+	config configStruct
+}{
+	{"metric_en", configStruct{
+		Language:        "en",
+		Icons:           "grzanka",
+		TimeFormat:      "15:04",
+		DateFormat:      "Jan 2",
+		TemperatureUnit: forecast.Celsius,
+		WindUnit:        forecast.KilometersPerHour,
+		PressureUnit:    forecast.Hectopascals,
+		PrecipUnit:      forecast.Millimeters,
+	}},
+	{"us_de", configStruct{
+		Language:        "de",
+		Icons:           "grzanka",
+		TimeFormat:      "3:04 PM",
+		DateFormat:      "1/2",
+		ShowDecimals:    true,
+		TemperatureUnit: forecast.Fahrenheit,
+		WindUnit:        forecast.MilesPerHour,
+		PressureUnit:    forecast.InchesOfMercury,
+		PrecipUnit:      forecast.Inches,
+	}},
+}
+
+// useOtherConfig replaces the global config with one that differs from every
+// item config, so that tests fail if items read it, until the test ends
+func useOtherConfig(t *testing.T) {
+	saved := config
+	config = configStruct{Language: "fr", TimeFormat: "15h04", TemperatureUnit: forecast.Kelvin}
+	t.Cleanup(func() { config = saved })
+}
+
+func TestDailyItems(t *testing.T) {
+	useOtherConfig(t)
+
+	loc := forecast.Location{Name: "Berlin, Germany", ShortName: "Berlin"}
+	rules := []rule{{Field: "low", Op: "<", Value: 0}}
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+
+	// A second day, so that the evening is between a sunset and a sunrise
+	weather := testWeather()
+	tomorrow := weather.Daily[0]
+	tomorrow.Date = day.AddDate(0, 0, 1)
+	tomorrow.Summary = "Clear during the day"
+	tomorrow.Icon = "clear"
+	tomorrow.Sunrise = tomorrow.Sunrise.AddDate(0, 0, 1)
+	tomorrow.Sunset = tomorrow.Sunset.AddDate(0, 0, 1)
+	weather.Daily = append(weather.Daily, tomorrow)
+
+	times := []struct {
+		name string
+		now  time.Time
+	}{
+		{"morning", day.Add(10 * time.Hour)},
+		{"night", day.Add(21 * time.Hour)},
+	}
+
+	for _, c := range itemConfigs {
+		for _, tm := range times {
+			name := "daily_" + c.name + "_" + tm.name + ".golden"
+			t.Run(name, func(t *testing.T) {
+				items := dailyItems(&c.config, loc, weather, rules, timeQuery{}, tm.now)
+				checkGoldenItems(t, name, items)
+			})
+		}
+	}
+}
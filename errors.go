@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/jason0x43/alfred-weather/providers"
+	"github.com/jason0x43/go-alfred"
+)
+
+// serviceKeyURLs are the pages where each service's API keys are managed
+var serviceKeyURLs = map[string]string{
+	serviceDarkSky:     "https://darksky.net/dev/account",
+	serviceOpenWeather: "https://home.openweathermap.org/api_keys",
+	serviceClimaCell:   "https://developer.climacell.co",
+}
+
+// optionsArg opens the options command
+var optionsArg = &alfred.ItemArg{Keyword: "options"}
+
+// isQuotaError indicates whether an error is due to a used up daily budget or
+// a service's request limit
+func isQuotaError(err error) bool {
+	var quotaErr *providers.QuotaError
+	var budgetErr quotaError
+	return errors.As(err, &quotaErr) || errors.As(err, &budgetErr)
+}
+
+// errorItem returns an item describing an error, with guidance on how to fix
+// it and, where there's something to do, an action
+func errorItem(err error) alfred.Item {
+	item := alfred.Item{
+		Title: err.Error(),
+		Icon:  "error.png",
+	}
+
+	var keyErr *providers.InvalidKeyError
+	var quotaErr *providers.QuotaError
+	var notFoundErr *providers.NotFoundError
+	var unavailableErr *providers.UnavailableError
+	var decodeErr *providers.DecodeError
+	var locationErr locationNotFoundError
+	var budgetErr quotaError
+
+	switch {
+	case errors.As(err, &keyErr):
+		item.Title = tr("%s rejected the API key", keyErr.Service)
+		item.Subtitle = tr("Press Enter to update the key in options")
+		item.Arg = optionsArg
+		if url, ok := serviceKeyURLs[keyErr.Service]; ok {
+			item.AddMod(alfred.ModCmd, alfred.ItemMod{
+				Subtitle: tr("Manage your API keys in a browser"),
+				Arg: &alfred.ItemArg{
+					Keyword: "daily",
+					Mode:    alfred.ModeDo,
+					Data:    alfred.Stringify(&dailyCfg{ToOpen: url}),
+				},
+			})
+		}
+
+	case errors.As(err, &quotaErr):
+		item.Title = tr("%s request limit reached", quotaErr.Service)
+		item.Subtitle = tr("Press Enter to change the daily budget in options")
+		item.Arg = optionsArg
+
+	case errors.As(err, &budgetErr):
+		item.Title = tr("%s request limit reached", budgetErr.service)
+		item.Subtitle = tr("Press Enter to change the daily budget in options")
+		item.Arg = optionsArg
+
+	case errors.As(err, &locationErr):
+		item.Title = tr("No location found for \"%s\"", string(locationErr))
+		item.Subtitle = tr("Try a city name, postal code or latitude,longitude")
+
+	case errors.As(err, &notFoundErr):
+		item.Title = tr("%s has no forecast for this location", notFoundErr.Service)
+		item.Subtitle = tr("Try a city name, postal code or latitude,longitude")
+
+	case errors.As(err, &unavailableErr):
+		item.Title = tr("%s is unavailable", unavailableErr.Service)
+		item.Subtitle = tr("Press Enter to choose another service, or try again later")
+		item.Arg = optionsArg
+
+	case errors.As(err, &decodeErr):
+		item.Title = tr("%s sent an unreadable response", decodeErr.Service)
+		item.Subtitle = tr("The service may have changed; check for a workflow update")
+
+	case validateConfig() != nil:
+		item.Subtitle = tr("Press Enter to open options")
+		item.Arg = optionsArg
+	}
+
+	return item
+}
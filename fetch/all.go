@@ -0,0 +1,76 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Errors is a set of errors from concurrent requests
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors in the set
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// All runs functions concurrently and waits for them to finish. When one
+// fails, the context passed to the others is cancelled. It returns nil if
+// every function succeeded, the error if one failed, or Errors if several
+// failed for reasons other than the cancellation.
+func All(ctx context.Context, fns ...func(context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(fns))
+	var failed bool
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, fn := range fns {
+		wg.Add(1)
+		go func(i int, fn func(context.Context) error) {
+			defer wg.Done()
+			err := fn(ctx)
+			if err == nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			// Once one function has failed, the others are expected to fail
+			// with a cancellation that isn't worth reporting
+			if failed && errors.Is(err, context.Canceled) {
+				return
+			}
+			errs[i] = err
+			failed = true
+			cancel()
+		}(i, fn)
+	}
+	wg.Wait()
+
+	var all Errors
+	for _, err := range errs {
+		if err != nil {
+			all = append(all, err)
+		}
+	}
+
+	switch len(all) {
+	case 0:
+		return nil
+	case 1:
+		return all[0]
+	}
+	return all
+}
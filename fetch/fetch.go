@@ -0,0 +1,270 @@
+// Package fetch makes HTTP GET requests to web APIs, with timeouts, retries
+// and typed errors.
+package fetch
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent identifies requests made by this package
+const DefaultUserAgent = "alfred-weather (+https://github.com/jason0x43/alfred-weather)"
+
+// Client makes requests to web APIs. Requests that fail with a network
+// error, a 5xx status or 429 Too Many Requests are retried with jittered
+// exponential backoff.
+type Client struct {
+	// HTTP is the underlying HTTP client
+	HTTP *http.Client
+
+	// UserAgent is sent with every request
+	UserAgent string
+
+	// Timeout limits each attempt of a request; zero means no limit
+	Timeout time.Duration
+
+	// Retries is the number of times a failed request is retried
+	Retries int
+
+	// Backoff is the base delay before the first retry. It doubles for each
+	// following retry.
+	Backoff time.Duration
+
+	// MaxWait is the longest delay before a retry. A request isn't retried if
+	// the server asks for a longer delay with Retry-After.
+	MaxWait time.Duration
+
+	// OnResponse, if set, is called with the header of every response the
+	// client receives, including error responses and failed attempts
+	OnResponse func(http.Header)
+}
+
+// New returns a Client with default settings. If httpClient is nil,
+// http.DefaultClient is used.
+func New(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		HTTP:      httpClient,
+		UserAgent: DefaultUserAgent,
+		Timeout:   10 * time.Second,
+		Retries:   2,
+		Backoff:   500 * time.Millisecond,
+		MaxWait:   5 * time.Second,
+	}
+}
+
+// Response is a successful response
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// StatusError is returned for responses with an error status
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return e.Status
+}
+
+// RetryAfter returns the delay requested by the server with a Retry-After
+// header, or zero if there isn't one
+func (e *StatusError) RetryAfter(now time.Time) time.Duration {
+	return retryAfter(e.Header, now)
+}
+
+// NetworkError is returned when a request can't be completed, such as when
+// a server can't be reached or a request times out
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return "network error: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout indicates whether the request timed out
+func (e *NetworkError) Timeout() bool {
+	var t interface{ Timeout() bool }
+	return errors.Is(e.Err, context.DeadlineExceeded) || (errors.As(e.Err, &t) && t.Timeout())
+}
+
+// DecodeError is returned when a response body can't be decoded
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "invalid response: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Get requests a URL, retrying if the request fails in a way that may be
+// temporary
+func (c *Client) Get(ctx context.Context, url string) (resp *Response, err error) {
+	for attempt := 0; ; attempt++ {
+		resp, err = c.get(ctx, url)
+		if err == nil || attempt >= c.Retries || !retryable(err) {
+			return
+		}
+
+		wait := c.backoff(attempt)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			if after := statusErr.RetryAfter(time.Now()); after > 0 {
+				if after > c.MaxWait {
+					return
+				}
+				wait = after
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// GetJSON requests a URL and decodes its JSON response into v
+func (c *Client) GetJSON(ctx context.Context, url string, v interface{}) (resp *Response, err error) {
+	if resp, err = c.Get(ctx, url); err != nil {
+		return
+	}
+	if err = json.Unmarshal(resp.Body, v); err != nil {
+		err = &DecodeError{Err: err}
+	}
+	return
+}
+
+// get makes a single attempt at a request
+func (c *Client) get(ctx context.Context, url string) (resp *Response, err error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var request *http.Request
+	if request, err = http.NewRequest("GET", url, nil); err != nil {
+		return
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("User-Agent", c.UserAgent)
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	var r *http.Response
+	if r, err = c.HTTP.Do(request); err != nil {
+		return nil, networkError(err)
+	}
+	defer r.Body.Close()
+
+	if c.OnResponse != nil {
+		c.OnResponse(r.Header)
+	}
+
+	var body io.Reader = r.Body
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(r.Body); err != nil {
+			return nil, &DecodeError{Err: err}
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	var data []byte
+	if data, err = ioutil.ReadAll(body); err != nil {
+		return nil, networkError(err)
+	}
+
+	if r.StatusCode >= 400 {
+		return nil, &StatusError{StatusCode: r.StatusCode, Status: r.Status, Header: r.Header, Body: data}
+	}
+
+	return &Response{StatusCode: r.StatusCode, Header: r.Header, Body: data}, nil
+}
+
+// backoff returns the delay before a retry, from half to all of the
+// exponential backoff for the attempt
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.Backoff << uint(attempt)
+	if d <= 0 || d > c.MaxWait {
+		d = c.MaxWait
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// networkError wraps a transport error. The URL is dropped from url.Errors
+// because query strings may include API keys.
+func networkError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return &NetworkError{Err: err}
+}
+
+// retryable indicates whether a failed request may succeed if retried
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return !errors.Is(netErr.Err, context.Canceled)
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header, which may be a number of seconds
+// or an HTTP date
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,365 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a test server that answers requests with a handler and
+// records when each one arrived
+type recorder struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	times    []time.Time
+}
+
+func newRecorder(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, attempt int)) *recorder {
+	rec := &recorder{}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.mu.Lock()
+		attempt := len(rec.requests)
+		rec.requests = append(rec.requests, r)
+		rec.times = append(rec.times, time.Now())
+		rec.mu.Unlock()
+		handler(w, r, attempt)
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+func (rec *recorder) attempts() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return len(rec.requests)
+}
+
+// testClient returns a client with short delays
+func testClient() *Client {
+	c := New(nil)
+	c.Backoff = 20 * time.Millisecond
+	c.MaxWait = 2 * time.Second
+	return c
+}
+
+func TestGetRetries5xx(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	c := testClient()
+	_, err := c.Get(context.Background(), rec.URL)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("error = %v, want a 503 StatusError", err)
+	}
+	if n := rec.attempts(); n != c.Retries+1 {
+		t.Fatalf("made %d attempts, want %d", n, c.Retries+1)
+	}
+
+	// Each delay is at least half of the doubling backoff
+	for i := 1; i < len(rec.times); i++ {
+		min := (c.Backoff << uint(i-1)) / 2
+		if gap := rec.times[i].Sub(rec.times[i-1]); gap < min {
+			t.Errorf("retry %d came after %v, want at least %v", i, gap, min)
+		}
+	}
+}
+
+func TestGetRecovers(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 0 {
+			http.Error(w, "busy", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	resp, err := testClient().Get(context.Background(), rec.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != "ok" || rec.attempts() != 2 {
+		t.Errorf("body = %q after %d attempts, want ok after 2", resp.Body, rec.attempts())
+	}
+}
+
+func TestGetNoRetry4xx(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+			http.Error(w, "no", status)
+		})
+
+		_, err := testClient().Get(context.Background(), rec.URL)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != status {
+			t.Errorf("%d: error = %v, want a StatusError", status, err)
+		}
+		if n := rec.attempts(); n != 1 {
+			t.Errorf("%d: made %d attempts, want 1", status, n)
+		}
+	}
+}
+
+func TestGetRetryAfter(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 0 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "busy", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	if _, err := testClient().Get(context.Background(), rec.URL); err != nil {
+		t.Fatal(err)
+	}
+	if gap := rec.times[1].Sub(rec.times[0]); gap < 900*time.Millisecond {
+		t.Errorf("retried after %v, want the requested second", gap)
+	}
+}
+
+func TestGetRetryAfterTooLong(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "busy", http.StatusTooManyRequests)
+	})
+
+	_, err := testClient().Get(context.Background(), rec.URL)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter(time.Now()) != time.Minute {
+		t.Errorf("error = %v, want a StatusError asking for a minute", err)
+	}
+	if n := rec.attempts(); n != 1 {
+		t.Errorf("made %d attempts, want 1 when the wait is longer than MaxWait", n)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 3 ", 3 * time.Second},
+		{"-5", 0},
+		{"Wed, 14 Oct 2026 10:01:30 GMT", 90 * time.Second},
+		{"Wednesday, 14-Oct-26 10:00:10 GMT", 10 * time.Second},
+		{"Wed, 14 Oct 2026 09:59:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, test := range tests {
+		header := http.Header{}
+		header.Set("Retry-After", test.value)
+		if got := retryAfter(header, now); got != test.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{Backoff: 100 * time.Millisecond, MaxWait: time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{2, 200 * time.Millisecond, 400 * time.Millisecond},
+		// capped at MaxWait
+		{5, 500 * time.Millisecond, time.Second},
+		{70, 500 * time.Millisecond, time.Second},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 50; i++ {
+			if d := c.backoff(test.attempt); d < test.min || d >= test.max {
+				t.Fatalf("backoff(%d) = %v, want [%v, %v)", test.attempt, d, test.min, test.max)
+			}
+		}
+	}
+}
+
+func TestGetGzip(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(`{"temp": 21.5}`))
+		gz.Close()
+
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+	})
+
+	var v struct{ Temp float64 }
+	if _, err := testClient().GetJSON(context.Background(), rec.URL, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Temp != 21.5 {
+		t.Errorf("temp = %v, want 21.5", v.Temp)
+	}
+	if got := rec.requests[0].Header.Get("Accept-Encoding"); got != "gzip" {
+		t.Errorf("Accept-Encoding = %q, want gzip", got)
+	}
+}
+
+func TestGetBadGzip(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("not gzip"))
+	})
+
+	_, err := testClient().Get(context.Background(), rec.URL)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("error = %v, want a DecodeError", err)
+	}
+}
+
+func TestGetUserAgent(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {})
+
+	c := testClient()
+	if _, err := c.Get(context.Background(), rec.URL); err != nil {
+		t.Fatal(err)
+	}
+	c.UserAgent = "test-agent/1.0"
+	if _, err := c.Get(context.Background(), rec.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{DefaultUserAgent, "test-agent/1.0"} {
+		if got := rec.requests[i].Header.Get("User-Agent"); got != want {
+			t.Errorf("User-Agent = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestGetTimeout(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	c := testClient()
+	c.Timeout = 50 * time.Millisecond
+	c.Retries = 1
+
+	start := time.Now()
+	_, err := c.Get(context.Background(), rec.URL)
+	elapsed := time.Since(start)
+
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("error = %v, want a timeout", err)
+	}
+	// The timeout applies to each attempt, so a timed out attempt is retried
+	if n := rec.attempts(); n != 2 {
+		t.Errorf("made %d attempts, want 2", n)
+	}
+	if elapsed > time.Second {
+		t.Errorf("took %v, want each attempt to stop after its timeout", elapsed)
+	}
+}
+
+func TestOnResponse(t *testing.T) {
+	rec := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		w.Header().Set("X-RateLimit-Remaining", "10")
+		if attempt == 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	})
+
+	var headers []http.Header
+	c := testClient()
+	c.OnResponse = func(h http.Header) { headers = append(headers, h) }
+	if _, err := c.Get(context.Background(), rec.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 2 || headers[0].Get("X-RateLimit-Remaining") != "10" {
+		t.Errorf("OnResponse was called with %v, want both responses", headers)
+	}
+}
+
+func TestAllCancelsOthers(t *testing.T) {
+	cancelled := make(chan struct{})
+	slow := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+		}
+	})
+	failing := newRecorder(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		http.Error(w, "no", http.StatusNotFound)
+	})
+
+	c := testClient()
+	start := time.Now()
+	err := All(context.Background(),
+		func(ctx context.Context) error {
+			_, err := c.Get(ctx, slow.URL)
+			return err
+		},
+		func(ctx context.Context) error {
+			// Fail once the slow request has started
+			for slow.attempts() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			_, err := c.Get(ctx, failing.URL)
+			return err
+		},
+	)
+
+	// Only the failure is reported, not the cancellation it caused
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("error = %v, want the 404", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow request wasn't cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %v, want All to return without waiting for the slow request", elapsed)
+	}
+	if n := slow.attempts(); n != 1 {
+		t.Errorf("slow request was attempted %d times, want 1 since cancelled requests aren't retried", n)
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	if err := All(context.Background(),
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error { return nil },
+	); err != nil {
+		t.Errorf("error = %v, want nil", err)
+	}
+
+	first, second := errors.New("first"), errors.New("second")
+	release := make(chan struct{})
+	err := All(context.Background(),
+		func(ctx context.Context) error { <-release; return first },
+		func(ctx context.Context) error { defer close(release); return second },
+	)
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 || !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("error = %v, want both errors", err)
+	}
+}
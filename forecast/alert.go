@@ -0,0 +1,84 @@
+package forecast
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Alert is a weather alert (e.g., severe thunderstorm)
+type Alert struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Severity    Severity  `json:"severity"`
+	Onset       time.Time `json:"onset"`
+	Expires     time.Time `json:"expires"`
+	Regions     []string  `json:"regions"`
+	Sender      string    `json:"sender"`
+	URL         string    `json:"url"`
+}
+
+// Severity is the normalized severity of an alert
+type Severity int
+
+// Alert severities, from least to most severe
+const (
+	SeverityUnknown Severity = iota
+	SeverityAdvisory
+	SeverityWatch
+	SeverityWarning
+	SeverityExtreme
+)
+
+var severityNames = map[Severity]string{
+	SeverityUnknown:  "Alert",
+	SeverityAdvisory: "Advisory",
+	SeverityWatch:    "Watch",
+	SeverityWarning:  "Warning",
+	SeverityExtreme:  "Extreme",
+}
+
+// String returns the display name of a severity
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// ParseSeverity determines an alert severity from a provider's severity
+// level or, failing that, from an alert title such as "Flood Watch"
+func ParseSeverity(value string) Severity {
+	value = strings.ToLower(value)
+
+	switch value {
+	case "extreme":
+		return SeverityExtreme
+	case "warning", "severe":
+		return SeverityWarning
+	case "watch", "moderate":
+		return SeverityWatch
+	case "advisory", "minor", "statement":
+		return SeverityAdvisory
+	}
+
+	switch {
+	case strings.Contains(value, "emergency"), strings.Contains(value, "extreme"):
+		return SeverityExtreme
+	case strings.Contains(value, "warning"):
+		return SeverityWarning
+	case strings.Contains(value, "watch"):
+		return SeverityWatch
+	case strings.Contains(value, "advisory"), strings.Contains(value, "statement"):
+		return SeverityAdvisory
+	}
+
+	return SeverityUnknown
+}
+
+// SortAlerts sorts alerts from most to least severe, and then by onset
+func SortAlerts(alerts []Alert) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if alerts[i].Severity != alerts[j].Severity {
+			return alerts[i].Severity > alerts[j].Severity
+		}
+		return alerts[i].Onset.Before(alerts[j].Onset)
+	})
+}
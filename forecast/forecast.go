@@ -0,0 +1,112 @@
+// Package forecast defines the provider-independent weather model. All
+// quantities are stored in SI-based units, and can be converted to other
+// units for display.
+package forecast
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Location is a named location
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	ShortName string
+	Name      string
+}
+
+// Conditions are the weather conditions at a point in time
+type Conditions struct {
+	Summary      string
+	Icon         string
+	Humidity     float64
+	Temp         Temperature
+	ApparentTemp Temperature
+	WindSpeed    Speed
+	WindGust     Speed
+	Pressure     Pressure
+	Visibility   Distance
+	Time         time.Time
+}
+
+// Daily is the forecast for a day
+type Daily struct {
+	Date     time.Time
+	Summary  string
+	Icon     string
+	HighTemp Temperature
+	LowTemp  Temperature
+	Sunrise  time.Time
+	Sunset   time.Time
+
+	// Precip is the chance of precipitation in percent, or -1 if unknown
+	Precip       int
+	PrecipAmount Precipitation
+	WindSpeed    Speed
+	WindGust     Speed
+}
+
+// Hourly is the forecast for an hour
+type Hourly struct {
+	Time         time.Time
+	Summary      string
+	Icon         string
+	Temp         Temperature
+	ApparentTemp Temperature
+
+	// Precip is the chance of precipitation in percent
+	Precip       int
+	PrecipAmount Precipitation
+	WindSpeed    Speed
+	WindGust     Speed
+}
+
+// Weather is the current conditions, forecasts and alerts for a location
+type Weather struct {
+	Current Conditions
+	Daily   []Daily
+	Hourly  []Hourly
+	Alerts  []Alert
+	URL     string
+}
+
+// IsAtNight indicates whether a given time is at night. Services don't report
+// sun times during polar day or night, so on those days the night icons of the
+// hourly or current forecast are used instead.
+func (w *Weather) IsAtNight(t time.Time) bool {
+	y, m, d := t.Date()
+	for _, day := range w.Daily {
+		dy, dm, dd := day.Date.In(t.Location()).Date()
+		if dy == y && dm == m && dd == d && (day.Sunrise.Unix() <= 0 || day.Sunset.Unix() <= 0) {
+			return w.hasNightIcon(t)
+		}
+	}
+
+	for i := 0; i < len(w.Daily)-1; i++ {
+		if t.After(w.Daily[i].Sunset) && t.Before(w.Daily[i+1].Sunrise) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNightIcon indicates whether the hourly forecast for a time, or the
+// current conditions if they're within an hour of it, have a night icon
+func (w *Weather) hasNightIcon(t time.Time) bool {
+	for _, h := range w.Hourly {
+		if !t.Before(h.Time) && t.Before(h.Time.Add(time.Hour)) {
+			return strings.HasPrefix(h.Icon, "nt_")
+		}
+	}
+	if diff := t.Sub(w.Current.Time); diff > -time.Hour && diff < time.Hour {
+		return strings.HasPrefix(w.Current.Icon, "nt_")
+	}
+	return false
+}
+
+// Provider is a forecasting service
+type Provider interface {
+	Forecast(context.Context, Location) (Weather, error)
+}
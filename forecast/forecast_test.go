@@ -0,0 +1,47 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsAtNight(t *testing.T) {
+	day := time.Date(2020, 6, 21, 0, 0, 0, 0, time.UTC)
+
+	normal := Weather{Daily: []Daily{
+		{Date: day, Sunrise: day.Add(5 * time.Hour), Sunset: day.Add(21 * time.Hour)},
+		{Date: day.AddDate(0, 0, 1), Sunrise: day.Add(29 * time.Hour), Sunset: day.Add(45 * time.Hour)},
+	}}
+	polar := Weather{
+		Daily: []Daily{
+			{Date: day, Sunrise: time.Unix(0, 0), Sunset: time.Unix(0, 0)},
+			{Date: day.AddDate(0, 0, 1), Sunrise: time.Unix(0, 0), Sunset: time.Unix(0, 0)},
+		},
+		Hourly: []Hourly{
+			{Time: day.Add(12 * time.Hour), Icon: "nt_cloudy"},
+			{Time: day.Add(13 * time.Hour), Icon: "cloudy"},
+		},
+		Current: Conditions{Time: day.Add(20 * time.Hour), Icon: "nt_clear"},
+	}
+
+	tests := []struct {
+		name    string
+		weather Weather
+		time    time.Time
+		night   bool
+	}{
+		{"day", normal, day.Add(12 * time.Hour), false},
+		{"after sunset", normal, day.Add(22 * time.Hour), true},
+		{"before sunrise", normal, day.Add(28 * time.Hour), true},
+		{"polar hour with a night icon", polar, day.Add(12*time.Hour + 30*time.Minute), true},
+		{"polar hour with a day icon", polar, day.Add(13 * time.Hour), false},
+		{"polar current conditions", polar, day.Add(20*time.Hour + 10*time.Minute), true},
+		{"polar with no forecast", polar, day.Add(3 * time.Hour), false},
+	}
+
+	for _, test := range tests {
+		if got := test.weather.IsAtNight(test.time); got != test.night {
+			t.Errorf("%s: IsAtNight = %v, want %v", test.name, got, test.night)
+		}
+	}
+}
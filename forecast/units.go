@@ -0,0 +1,228 @@
+package forecast
+
+import (
+	"math"
+	"strconv"
+)
+
+// TemperatureUnit is a unit of temperature
+type TemperatureUnit string
+
+// Temperature units
+const (
+	Celsius    TemperatureUnit = "C"
+	Fahrenheit TemperatureUnit = "F"
+	Kelvin     TemperatureUnit = "K"
+)
+
+// WindUnit is a unit of wind speed
+type WindUnit string
+
+// Wind speed units
+const (
+	KilometersPerHour WindUnit = "km/h"
+	MetersPerSecond   WindUnit = "m/s"
+	MilesPerHour      WindUnit = "mph"
+	Knots             WindUnit = "knots"
+	Beaufort          WindUnit = "Beaufort"
+)
+
+// PressureUnit is a unit of atmospheric pressure
+type PressureUnit string
+
+// Pressure units
+const (
+	Hectopascals         PressureUnit = "hPa"
+	InchesOfMercury      PressureUnit = "inHg"
+	MillimetersOfMercury PressureUnit = "mmHg"
+)
+
+// PrecipUnit is a unit of precipitation amount
+type PrecipUnit string
+
+// Precipitation units
+const (
+	Millimeters PrecipUnit = "mm"
+	Inches      PrecipUnit = "in"
+)
+
+// DistanceUnit is a unit of distance
+type DistanceUnit string
+
+// Distance units
+const (
+	Kilometers DistanceUnit = "km"
+	Miles      DistanceUnit = "mi"
+)
+
+// Temperature is a temperature in degrees Celsius
+type Temperature float64
+
+// In returns the temperature in the given units
+func (t Temperature) In(u TemperatureUnit) float64 {
+	switch u {
+	case Fahrenheit:
+		return float64(t)*(9.0/5.0) + 32.0
+	case Kelvin:
+		return float64(t) + 273.15
+	}
+	return float64(t)
+}
+
+// Format returns the temperature in the given units, with a unit symbol
+func (t Temperature) Format(u TemperatureUnit, decimals int) string {
+	return FormatNumber(t.In(u), decimals) + u.Symbol()
+}
+
+// Symbol returns the display symbol for a temperature unit
+func (u TemperatureUnit) Symbol() string {
+	if u == Kelvin {
+		return "K"
+	}
+	return "°" + string(u)
+}
+
+// TemperatureFrom converts a value in the given units to a Temperature
+func TemperatureFrom(value float64, u TemperatureUnit) Temperature {
+	switch u {
+	case Fahrenheit:
+		return Temperature((value - 32.0) * (5.0 / 9.0))
+	case Kelvin:
+		return Temperature(value - 273.15)
+	}
+	return Temperature(value)
+}
+
+// Speed is a speed in meters per second
+type Speed float64
+
+// beaufortLimits are the upper bounds in m/s of Beaufort forces 0 - 11
+var beaufortLimits = []float64{0.5, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+
+// In returns the speed in the given units
+func (s Speed) In(u WindUnit) float64 {
+	switch u {
+	case KilometersPerHour:
+		return float64(s) * 3.6
+	case MilesPerHour:
+		return float64(s) * 2.236936
+	case Knots:
+		return float64(s) * 1.943844
+	case Beaufort:
+		force := 0
+		for force < len(beaufortLimits) && float64(s) >= beaufortLimits[force] {
+			force++
+		}
+		return float64(force)
+	}
+	return float64(s)
+}
+
+// Format returns the speed in the given units, with a unit symbol
+func (s Speed) Format(u WindUnit) string {
+	if u == Beaufort {
+		return "Bft " + FormatNumber(s.In(u), 0)
+	}
+	return FormatNumber(s.In(u), 0) + " " + string(u)
+}
+
+// SpeedFrom converts a value in the given units to a Speed. Beaufort forces
+// are converted to the lowest speed of the force.
+func SpeedFrom(value float64, u WindUnit) Speed {
+	switch u {
+	case KilometersPerHour:
+		return Speed(value / 3.6)
+	case MilesPerHour:
+		return Speed(value / 2.236936)
+	case Knots:
+		return Speed(value / 1.943844)
+	case Beaufort:
+		force := int(math.Round(value))
+		if force <= 0 {
+			return 0
+		}
+		if force > len(beaufortLimits) {
+			force = len(beaufortLimits)
+		}
+		return Speed(beaufortLimits[force-1])
+	}
+	return Speed(value)
+}
+
+// Pressure is an atmospheric pressure in hectopascals
+type Pressure float64
+
+// In returns the pressure in the given units
+func (p Pressure) In(u PressureUnit) float64 {
+	switch u {
+	case InchesOfMercury:
+		return float64(p) * 0.02953
+	case MillimetersOfMercury:
+		return float64(p) * 0.750062
+	}
+	return float64(p)
+}
+
+// Format returns the pressure in the given units, with a unit symbol
+func (p Pressure) Format(u PressureUnit) string {
+	if u == InchesOfMercury {
+		return FormatNumber(p.In(u), 2) + " " + string(u)
+	}
+	return FormatNumber(p.In(u), 0) + " " + string(u)
+}
+
+// Precipitation is an amount of precipitation in millimeters
+type Precipitation float64
+
+// In returns the precipitation amount in the given units
+func (p Precipitation) In(u PrecipUnit) float64 {
+	if u == Inches {
+		return float64(p) / 25.4
+	}
+	return float64(p)
+}
+
+// Format returns the precipitation amount in the given units, with a unit
+// symbol
+func (p Precipitation) Format(u PrecipUnit) string {
+	if u == Inches {
+		return FormatNumber(p.In(u), 2) + " " + string(u)
+	}
+	return FormatNumber(p.In(u), 1) + " " + string(u)
+}
+
+// PrecipitationFrom converts a value in the given units to a Precipitation
+func PrecipitationFrom(value float64, u PrecipUnit) Precipitation {
+	if u == Inches {
+		return Precipitation(value * 25.4)
+	}
+	return Precipitation(value)
+}
+
+// Distance is a distance in kilometers
+type Distance float64
+
+// In returns the distance in the given units
+func (d Distance) In(u DistanceUnit) float64 {
+	if u == Miles {
+		return float64(d) * 0.621371
+	}
+	return float64(d)
+}
+
+// Format returns the distance in the given units, with a unit symbol
+func (d Distance) Format(u DistanceUnit) string {
+	return FormatNumber(d.In(u), 0) + " " + string(u)
+}
+
+// FormatNumber formats a value with a fixed number of decimal places, rounding
+// halves away from zero. Values that round to zero are never shown as "-0".
+func FormatNumber(val float64, decimals int) string {
+	scale := math.Pow(10, float64(decimals))
+	val = math.Round(val*scale) / scale
+	if val == 0 {
+		// normalize negative zero
+		val = 0
+	}
+	return strconv.FormatFloat(val, 'f', decimals, 64)
+}
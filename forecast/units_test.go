@@ -0,0 +1,72 @@
+package forecast
+
+import "testing"
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		val      float64
+		decimals int
+		want     string
+	}{
+		{-3.7, 0, "-4"},
+		{-3.2, 0, "-3"},
+		{-0.4, 0, "0"},
+		{-0.5, 0, "-1"},
+		{0.5, 0, "1"},
+		{2.5, 0, "3"},
+		{-2.5, 0, "-3"},
+		{0, 0, "0"},
+		{21.04, 1, "21.0"},
+		{1.25, 1, "1.3"},
+		{-1.25, 1, "-1.3"},
+		{-0.04, 1, "0.0"},
+		{-0.06, 1, "-0.1"},
+		{29.9213, 2, "29.92"},
+	}
+
+	for _, test := range tests {
+		if got := FormatNumber(test.val, test.decimals); got != test.want {
+			t.Errorf("FormatNumber(%v, %d) = %q, want %q", test.val, test.decimals, got, test.want)
+		}
+	}
+}
+
+func TestQuantityFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"negative Celsius", Temperature(-3.7).Format(Celsius, 0), "-4°C"},
+		{"negative zero Celsius", Temperature(-0.4).Format(Celsius, 0), "0°C"},
+		{"negative zero Fahrenheit", Temperature(-17.9).Format(Fahrenheit, 0), "0°F"},
+		{"one decimal", Temperature(-3.75).Format(Celsius, 1), "-3.8°C"},
+		{"Kelvin", Temperature(0).Format(Kelvin, 0), "273K"},
+		{"km/h", Speed(10).Format(KilometersPerHour), "36 km/h"},
+		{"Beaufort", Speed(5).Format(Beaufort), "Bft 3"},
+		{"inHg", Pressure(1013.25).Format(InchesOfMercury), "29.92 inHg"},
+		{"hPa", Pressure(1013.5).Format(Hectopascals), "1014 hPa"},
+		{"mm", Precipitation(0.25).Format(Millimeters), "0.3 mm"},
+		{"in", Precipitation(25.4).Format(Inches), "1.00 in"},
+		{"mi", Distance(10).Format(Miles), "6 mi"},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestConversionRoundTrip(t *testing.T) {
+	for _, u := range []TemperatureUnit{Celsius, Fahrenheit, Kelvin} {
+		if got := TemperatureFrom(Temperature(-3.7).In(u), u).Format(Celsius, 1); got != "-3.7°C" {
+			t.Errorf("%s: got %s, want -3.7°C", u, got)
+		}
+	}
+	for _, u := range []WindUnit{KilometersPerHour, MetersPerSecond, MilesPerHour, Knots} {
+		if got := SpeedFrom(Speed(12).In(u), u).Format(MetersPerSecond); got != "12 m/s" {
+			t.Errorf("%s: got %s, want 12 m/s", u, got)
+		}
+	}
+}
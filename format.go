@@ -0,0 +1,17 @@
+package main
+
+import "math"
+
+// round rounds a value to the nearest integer, rounding halves away from zero
+func round(val float64) int64 {
+	return int64(math.Round(val))
+}
+
+// temperatureDecimals returns the number of decimal places to use when
+// displaying temperatures
+func (c *configStruct) temperatureDecimals() int {
+	if c.ShowDecimals {
+		return 1
+	}
+	return 0
+}
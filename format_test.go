@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		val  float64
+		want int64
+	}{
+		{-3.7, -4},
+		{-3.5, -4},
+		{-0.4, 0},
+		{0.4, 0},
+		{2.5, 3},
+		{-2.5, -3},
+	}
+
+	for _, test := range tests {
+		if got := round(test.val); got != test.want {
+			t.Errorf("round(%v) = %d, want %d", test.val, got, test.want)
+		}
+	}
+}
+
+func TestFormatTemperature(t *testing.T) {
+	defer func(c configStruct) { config = c }(config)
+
+	tests := []struct {
+		temp     forecast.Temperature
+		unit     forecast.TemperatureUnit
+		decimals bool
+		want     string
+	}{
+		{-3.7, forecast.Celsius, false, "-4°C"},
+		{-0.4, forecast.Celsius, false, "0°C"},
+		{-0.04, forecast.Celsius, true, "0.0°C"},
+		{-3.7, forecast.Celsius, true, "-3.7°C"},
+		{-20.5, forecast.Fahrenheit, false, "-5°F"},
+		{21.25, forecast.Celsius, true, "21.3°C"},
+	}
+
+	for _, test := range tests {
+		config.TemperatureUnit = test.unit
+		config.ShowDecimals = test.decimals
+		if got := formatTemperature(test.temp); got != test.want {
+			t.Errorf("formatTemperature(%v %s, decimals %v) = %q, want %q", float64(test.temp), test.unit, test.decimals, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package geocoding looks up the coordinates of named places.
+package geocoding
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/jason0x43/alfred-weather/fetch"
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// Endpoints of the OpenStreetMap Nominatim service
+const (
+	DefaultURL        = "https://nominatim.openstreetmap.org/search"
+	DefaultReverseURL = "https://nominatim.openstreetmap.org/reverse"
+)
+
+type geoLocation struct {
+	FormattedAddress string `json:"display_name"`
+	Lat              string `json:"lat"`
+	Lng              string `json:"lon"`
+}
+
+type geoResults []geoLocation
+
+// geoReverseResult is the result of a reverse lookup, which has an error
+// message rather than a location if nothing was found
+type geoReverseResult struct {
+	geoLocation
+	Error string `json:"error"`
+}
+
+// geocode converts a search result to a Geocode
+func (loc *geoLocation) geocode() (gc Geocode) {
+	gc.Name = loc.FormattedAddress
+	gc.Latitude, _ = strconv.ParseFloat(loc.Lat, 64)
+	gc.Longitude, _ = strconv.ParseFloat(loc.Lng, 64)
+	return
+}
+
+// Geocode is a geographic location
+type Geocode struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// Location converts a Geocode to a forecast location
+func (g *Geocode) Location() (l forecast.Location) {
+	l.Latitude = g.Latitude
+	l.Longitude = g.Longitude
+	l.Name = g.Name
+	l.ShortName = g.Name
+	return
+}
+
+// Geocoder is a geocoding service handle
+type Geocoder struct {
+	// URL is the search endpoint
+	URL string
+
+	// ReverseURL is the reverse lookup endpoint
+	ReverseURL string
+
+	// Client makes the geocoder's HTTP requests
+	Client *fetch.Client
+}
+
+// NewGeocoder returns a Geocoder for the default endpoint. If client is nil,
+// a fetch.Client with default settings is used.
+func NewGeocoder(client *fetch.Client) *Geocoder {
+	if client == nil {
+		client = fetch.New(nil)
+	}
+	return &Geocoder{URL: DefaultURL, ReverseURL: DefaultReverseURL, Client: client}
+}
+
+// Locate returns the possible geocodes for a location
+func (g *Geocoder) Locate(ctx context.Context, location string) (l []Geocode, err error) {
+	query := url.Values{}
+	query.Set("q", location)
+	query.Set("format", "json")
+	query.Set("addressDetails", "1")
+
+	var r geoResults
+	if _, err = g.Client.GetJSON(ctx, g.URL+"?"+query.Encode(), &r); err != nil {
+		return
+	}
+
+	for _, res := range r {
+		l = append(l, res.geocode())
+	}
+
+	return
+}
+
+// Reverse returns the geocode of the place at a pair of coordinates. The
+// returned bool is false if there's no place there.
+func (g *Geocoder) Reverse(ctx context.Context, latitude, longitude float64) (gc Geocode, found bool, err error) {
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(latitude, 'f', -1, 64))
+	query.Set("lon", strconv.FormatFloat(longitude, 'f', -1, 64))
+	query.Set("format", "json")
+
+	var r geoReverseResult
+	if _, err = g.Client.GetJSON(ctx, g.ReverseURL+"?"+query.Encode(), &r); err != nil {
+		return
+	}
+	if r.Error != "" {
+		return
+	}
+
+	return r.geocode(), true, nil
+}
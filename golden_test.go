@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jason0x43/go-alfred"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// checkGolden compares output with a golden file in testdata, or rewrites the
+// file when the tests are run with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output doesn't match %s (run the tests with -update to accept it)\ngot:\n%s", path, got)
+	}
+}
+
+// goldenItem is the part of a script filter item that's compared with golden
+// files
+type goldenItem struct {
+	Title        string     `json:"title"`
+	Subtitle     string     `json:"subtitle,omitempty"`
+	Icon         string     `json:"icon,omitempty"`
+	Autocomplete string     `json:"autocomplete,omitempty"`
+	Arg          *goldenArg `json:"arg,omitempty"`
+}
+
+type goldenArg struct {
+	Keyword string `json:"keyword,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+	Data    string `json:"data,omitempty"`
+}
+
+// checkGoldenItems compares script filter items with a golden file
+func checkGoldenItems(t *testing.T, name string, items []alfred.Item) {
+	t.Helper()
+
+	golden := []goldenItem{}
+	for _, item := range items {
+		g := goldenItem{
+			Title:        item.Title,
+			Subtitle:     item.Subtitle,
+			Icon:         item.Icon,
+			Autocomplete: item.Autocomplete,
+		}
+		if item.Arg != nil {
+			g.Arg = &goldenArg{Keyword: item.Arg.Keyword, Mode: fmt.Sprint(item.Arg.Mode), Data: item.Arg.Data}
+		}
+		golden = append(golden, g)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(golden); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, name, buf.Bytes())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jason0x43/alfred-weather/forecast"
 	"github.com/jason0x43/go-alfred"
 )
 
@@ -31,21 +32,32 @@ func (c HourlyCommand) Items(arg, data string) (items []alfred.Item, err error)
 		}
 	}
 
-	var weather Weather
-	var loc Location
-	if loc, weather, err = getWeather(arg); err != nil {
-		return
+	now := clock.Now()
+	query, when := parseTimeQuery(arg, now)
+
+	var weather forecast.Weather
+	var loc forecast.Location
+	if loc, weather, err = getWeather(query, now); err != nil {
+		return []alfred.Item{errorItem(err)}, nil
 	}
 
-	var startTime time.Time
+	var start time.Time
 	if cfg.Start != nil {
-		startTime = *cfg.Start
+		start = *cfg.Start
+	} else if when.IsSet() {
+		start = when.Time.Truncate(time.Hour)
 	} else if len(weather.Hourly) > 0 {
-		startTime = weather.Hourly[0].Time
+		start = weather.Hourly[0].Time
 	}
 
+	return hourlyItems(&config, loc, weather, start, now), nil
+}
+
+// hourlyItems returns the items for a location's hourly forecast from a start
+// time. Text is formatted according to c.
+func hourlyItems(c *configStruct, loc forecast.Location, weather forecast.Weather, start, now time.Time) (items []alfred.Item) {
 	heading := alfred.Item{
-		Title:    "Weather for " + loc.Name,
+		Title:    c.tr("Weather for %s", loc.Name),
 		Subtitle: alfred.Line,
 		Arg: &alfred.ItemArg{
 			Keyword: "daily",
@@ -54,7 +66,7 @@ func (c HourlyCommand) Items(arg, data string) (items []alfred.Item, err error)
 
 	if weather.URL != "" {
 		heading.AddMod(alfred.ModCmd, alfred.ItemMod{
-			Subtitle: "Open this forecast in a browser",
+			Subtitle: c.tr("Open this forecast in a browser"),
 			Arg: &alfred.ItemArg{
 				Keyword: "daily",
 				Mode:    alfred.ModeDo,
@@ -65,15 +77,10 @@ func (c HourlyCommand) Items(arg, data string) (items []alfred.Item, err error)
 
 	items = append(items, heading)
 
-	deg := "F"
-	if config.Units == unitsMetric {
-		deg = "C"
-	}
-
-	addAlertItems(&weather, &items)
+	items = append(items, alertItems(c, &weather, now)...)
 
 	for _, entry := range weather.Hourly {
-		if entry.Time.Before(startTime) {
+		if entry.Time.Before(start) {
 			continue
 		}
 
@@ -81,9 +88,9 @@ func (c HourlyCommand) Items(arg, data string) (items []alfred.Item, err error)
 		icon := entry.Icon
 
 		item := alfred.Item{
-			Title:    entry.Time.Format("Mon "+config.TimeFormat) + ": " + conditions,
-			Subtitle: fmt.Sprintf("%d°%s (%d°%s)   ☂ %d%%", entry.Temp.Int64(), deg, entry.ApparentTemp.Int64(), deg, entry.Precip),
-			Icon:     getIconFile(icon),
+			Title:    c.shortWeekday(entry.Time) + " " + entry.Time.Format(c.TimeFormat) + ": " + conditions,
+			Subtitle: hourlySubtitle(c, entry),
+			Icon:     c.iconFile(icon),
 		}
 
 		items = append(items, item)
@@ -92,6 +99,17 @@ func (c HourlyCommand) Items(arg, data string) (items []alfred.Item, err error)
 	return
 }
 
+func hourlySubtitle(c *configStruct, entry forecast.Hourly) string {
+	subtitle := fmt.Sprintf("%s (%s)", c.formatTemperature(entry.Temp), c.formatTemperature(entry.ApparentTemp))
+	if entry.Precip != -1 {
+		subtitle += fmt.Sprintf("   ☂ %d%%", entry.Precip)
+	}
+	if entry.WindSpeed > 0 {
+		subtitle += fmt.Sprintf("   ⚑ %s", c.formatSpeed(entry.WindSpeed))
+	}
+	return subtitle
+}
+
 type hourlyConfig struct {
 	Start *time.Time
 }
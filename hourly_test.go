@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+func TestHourlyItems(t *testing.T) {
+	useOtherConfig(t)
+
+	loc := forecast.Location{Name: "Berlin, Germany", ShortName: "Berlin"}
+	weather := testWeather()
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+	for _, c := range itemConfigs {
+		name := "hourly_" + c.name + ".golden"
+		t.Run(name, func(t *testing.T) {
+			items := hourlyItems(&c.config, loc, weather, weather.Hourly[0].Time, now)
+			checkGoldenItems(t, name, items)
+		})
+	}
+}
+
+func TestRuleItems(t *testing.T) {
+	useOtherConfig(t)
+
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	rules := []rule{
+		{Field: "rain", Op: ">", Value: 20, When: "tomorrow"},
+		{Field: "precip", Op: "<", Value: 20},
+		{Field: "high", Op: ">", Value: 30},
+	}
+
+	for _, c := range itemConfigs {
+		name := "rules_" + c.name + ".golden"
+		t.Run(name, func(t *testing.T) {
+			checkGoldenItems(t, name, ruleItems(&c.config, rules, rainyWeather(now), now))
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultLanguage = "en"
+
+// Languages are the available UI languages
+var Languages = []string{
+	"en",
+	"de",
+	"es",
+	"fr",
+	"nl",
+}
+
+// languageNames are the display names of the available languages
+var languageNames = map[string]string{
+	"en": "English",
+	"de": "Deutsch",
+	"es": "Español",
+	"fr": "Français",
+	"nl": "Nederlands",
+}
+
+// uiStrings are translations of fixed UI strings, keyed by language and then
+// by the English string
+var uiStrings = map[string]map[string]string{
+	"de": {
+		"Weather for %s":                  "Wetter für %s",
+		"Currently: %s":                   "Aktuell: %s",
+		"Today":                           "Heute",
+		"Tonight":                         "Heute Nacht",
+		"Tomorrow":                        "Morgen",
+		"Until %s":                        "Bis %s",
+		" on %s":                          " am %s",
+		"Open this forecast in a browser": "Diese Vorhersage im Browser öffnen",
+		"Refreshed!":                      "Aktualisiert!",
+		"Data will be reloaded on the next forecast":              "Die Daten werden bei der nächsten Vorhersage neu geladen",
+		"Add a location, or leave blank for the default location": "Ort eingeben oder leer lassen für den Standardort",
+		"Next rain":                           "Nächster Regen",
+		"Next snow":                           "Nächster Schnee",
+		"Dry":                                 "Trocken",
+		"Sunny":                               "Sonnig",
+		"Below %d°":                           "Unter %d°",
+		"Above %d°":                           "Über %d°",
+		"%d hour(s)":                          "%d Stunde(n)",
+		"Nothing matched in the next %d days": "Keine Übereinstimmung in den nächsten %d Tagen",
+		"rain":                                "Regen",
+		"snow":                                "Schnee",
+		"dry weather":                         "trockenes Wetter",
+		"sun":                                 "Sonne",
+		"temperatures below %d°":              "Temperaturen unter %d°",
+		"temperatures above %d°":              "Temperaturen über %d°",
+		"No %s in the forecast":               "%s: nicht in der Vorhersage",
+		"Alert":                               "Warnung",
+		"Advisory":                            "Hinweis",
+		"Watch":                               "Vorwarnung",
+		"Warning":                             "Unwetterwarnung",
+		"Extreme":                             "Extremes Unwetter",
+		"From %s":                             "Ab %s",
+		"No active alerts":                    "Keine aktiven Warnungen",
+		"Checked %d location(s)":              "%d Ort(e) geprüft",
+		"Copied alert text":                   "Warnungstext kopiert",
+		"Regions":                             "Gebiete",
+		"Open this alert in a browser":        "Diese Warnung im Browser öffnen",
+		"Copy the full alert text":            "Den vollständigen Warnungstext kopieren",
+		"Show in Large Type":                  "In großer Schrift anzeigen",
+		"Rule matched: %s":                    "Regel erfüllt: %s",
+		"%s rejected the API key":             "%s hat den API-Schlüssel abgelehnt",
+		"Press Enter to update the key in options":                  "Enter drücken, um den Schlüssel in den Optionen zu ändern",
+		"Manage your API keys in a browser":                         "API-Schlüssel im Browser verwalten",
+		"%s request limit reached":                                  "Anfragelimit von %s erreicht",
+		"Press Enter to change the daily budget in options":         "Enter drücken, um das Tagesbudget in den Optionen zu ändern",
+		"No location found for \"%s\"":                              "Kein Ort gefunden für „%s“",
+		"Try a city name, postal code or latitude,longitude":        "Stadtname, Postleitzahl oder Breite,Länge eingeben",
+		"%s has no forecast for this location":                      "%s hat keine Vorhersage für diesen Ort",
+		"%s is unavailable":                                         "%s ist nicht erreichbar",
+		"Press Enter to choose another service, or try again later": "Enter drücken, um einen anderen Dienst zu wählen, oder später erneut versuchen",
+		"%s sent an unreadable response":                            "%s hat eine unlesbare Antwort gesendet",
+		"The service may have changed; check for a workflow update": "Der Dienst hat sich möglicherweise geändert; nach einem Workflow-Update suchen",
+		"Key too short to check":                                    "Schlüssel zu kurz zum Prüfen",
+		"✗ %s rejected this key":                                    "✗ %s hat diesen Schlüssel abgelehnt",
+		"Couldn't check the key: %v":                                "Schlüssel konnte nicht geprüft werden: %v",
+		"✓ key valid":                                               "✓ Schlüssel gültig",
+		"✓ key valid, %d calls left today":                          "✓ Schlüssel gültig, heute noch %d Anfragen",
+		"Press Enter to open options":                               "Enter drücken, um die Optionen zu öffnen",
+		"Day":                                                       "Tag",
+		"Conditions":                                                "Wetter",
+		"Low":                                                       "Tief",
+		"High":                                                      "Hoch",
+		"Precip":                                                    "Niederschl.",
+		"Sunrise":                                                   "Aufgang",
+		"Sunset":                                                    "Untergang",
+		"Time":                                                      "Zeit",
+		"Temp":                                                      "Temp.",
+		"Feels":                                                     "Gefühlt",
+		"Wind":                                                      "Wind",
+		"Press Enter to remove this rule":                           "Enter drücken, um diese Regel zu entfernen",
+		"Add a rule":                                                "Regel hinzufügen",
+		"e.g. \"low < 0C tonight\", \"gust > 60km/h tomorrow\", \"precip >= 70\"": "z. B. „low < 0C tonight“, „gust > 60km/h tomorrow“, „precip >= 70“",
+		"Invalid rule": "Ungültige Regel",
+		"Add rule: %s": "Regel hinzufügen: %s",
+		"Matches will be shown in the daily forecast and reported by the watcher": "Treffer werden in der Tagesvorhersage angezeigt und vom Watcher gemeldet",
+		"Forecast conditions to be notified about":                                "Wetterbedingungen, über die benachrichtigt wird",
+		"Added rule":         "Regel hinzugefügt",
+		"Removed rule":       "Regel entfernt",
+		"Error saving rules": "Fehler beim Speichern der Regeln",
+	},
+	"es": {
+		"Weather for %s":                  "El tiempo en %s",
+		"Currently: %s":                   "Ahora: %s",
+		"Today":                           "Hoy",
+		"Tonight":                         "Esta noche",
+		"Tomorrow":                        "Mañana",
+		"Until %s":                        "Hasta las %s",
+		" on %s":                          " del %s",
+		"Open this forecast in a browser": "Abrir este pronóstico en un navegador",
+		"Refreshed!":                      "¡Actualizado!",
+		"Data will be reloaded on the next forecast":              "Los datos se recargarán en el próximo pronóstico",
+		"Add a location, or leave blank for the default location": "Añade un lugar, o déjalo en blanco para usar el lugar predeterminado",
+		"Next rain":                           "Próxima lluvia",
+		"Next snow":                           "Próxima nevada",
+		"Dry":                                 "Seco",
+		"Sunny":                               "Soleado",
+		"Below %d°":                           "Por debajo de %d°",
+		"Above %d°":                           "Por encima de %d°",
+		"%d hour(s)":                          "%d hora(s)",
+		"Nothing matched in the next %d days": "Sin coincidencias en los próximos %d días",
+		"rain":                                "lluvia",
+		"snow":                                "nieve",
+		"dry weather":                         "tiempo seco",
+		"sun":                                 "sol",
+		"temperatures below %d°":              "temperaturas por debajo de %d°",
+		"temperatures above %d°":              "temperaturas por encima de %d°",
+		"No %s in the forecast":               "Sin %s en el pronóstico",
+		"Alert":                               "Alerta",
+		"Advisory":                            "Aviso",
+		"Watch":                               "Vigilancia",
+		"Warning":                             "Advertencia",
+		"Extreme":                             "Extremo",
+		"From %s":                             "Desde %s",
+		"No active alerts":                    "No hay alertas activas",
+		"Checked %d location(s)":              "%d lugar(es) comprobado(s)",
+		"Copied alert text":                   "Texto de la alerta copiado",
+		"Regions":                             "Regiones",
+		"Open this alert in a browser":        "Abrir esta alerta en un navegador",
+		"Copy the full alert text":            "Copiar el texto completo de la alerta",
+		"Show in Large Type":                  "Mostrar en letra grande",
+		"Rule matched: %s":                    "Regla cumplida: %s",
+		"%s rejected the API key":             "%s rechazó la clave de API",
+		"Press Enter to update the key in options":                  "Pulsa Intro para cambiar la clave en las opciones",
+		"Manage your API keys in a browser":                         "Gestionar tus claves de API en el navegador",
+		"%s request limit reached":                                  "Límite de solicitudes de %s alcanzado",
+		"Press Enter to change the daily budget in options":         "Pulsa Intro para cambiar el presupuesto diario en las opciones",
+		"No location found for \"%s\"":                              "No se encontró ningún lugar para \"%s\"",
+		"Try a city name, postal code or latitude,longitude":        "Prueba con una ciudad, un código postal o latitud,longitud",
+		"%s has no forecast for this location":                      "%s no tiene pronóstico para este lugar",
+		"%s is unavailable":                                         "%s no está disponible",
+		"Press Enter to choose another service, or try again later": "Pulsa Intro para elegir otro servicio, o inténtalo más tarde",
+		"%s sent an unreadable response":                            "%s envió una respuesta ilegible",
+		"The service may have changed; check for a workflow update": "Puede que el servicio haya cambiado; busca una actualización del workflow",
+		"Key too short to check":                                    "Clave demasiado corta para comprobarla",
+		"✗ %s rejected this key":                                    "✗ %s rechazó esta clave",
+		"Couldn't check the key: %v":                                "No se pudo comprobar la clave: %v",
+		"✓ key valid":                                               "✓ clave válida",
+		"✓ key valid, %d calls left today":                          "✓ clave válida, quedan %d llamadas hoy",
+		"Press Enter to open options":                               "Pulsa Intro para abrir las opciones",
+		"Day":                                                       "Día",
+		"Conditions":                                                "Condiciones",
+		"Low":                                                       "Mín.",
+		"High":                                                      "Máx.",
+		"Precip":                                                    "Precip.",
+		"Sunrise":                                                   "Amanecer",
+		"Sunset":                                                    "Atardecer",
+		"Time":                                                      "Hora",
+		"Temp":                                                      "Temp.",
+		"Feels":                                                     "Sensación",
+		"Wind":                                                      "Viento",
+		"Press Enter to remove this rule":                           "Pulsa Enter para eliminar esta regla",
+		"Add a rule":                                                "Añadir una regla",
+		"e.g. \"low < 0C tonight\", \"gust > 60km/h tomorrow\", \"precip >= 70\"": "p. ej. «low < 0C tonight», «gust > 60km/h tomorrow», «precip >= 70»",
+		"Invalid rule": "Regla no válida",
+		"Add rule: %s": "Añadir regla: %s",
+		"Matches will be shown in the daily forecast and reported by the watcher": "Las coincidencias se mostrarán en el pronóstico diario y las notificará el vigilante",
+		"Forecast conditions to be notified about":                                "Condiciones del pronóstico que se notificarán",
+		"Added rule":         "Regla añadida",
+		"Removed rule":       "Regla eliminada",
+		"Error saving rules": "Error al guardar las reglas",
+	},
+	"fr": {
+		"Weather for %s":                  "Météo pour %s",
+		"Currently: %s":                   "Actuellement : %s",
+		"Today":                           "Aujourd'hui",
+		"Tonight":                         "Ce soir",
+		"Tomorrow":                        "Demain",
+		"Until %s":                        "Jusqu'à %s",
+		" on %s":                          " le %s",
+		"Open this forecast in a browser": "Ouvrir cette prévision dans un navigateur",
+		"Refreshed!":                      "Actualisé !",
+		"Data will be reloaded on the next forecast":              "Les données seront rechargées à la prochaine prévision",
+		"Add a location, or leave blank for the default location": "Ajoutez un lieu, ou laissez vide pour le lieu par défaut",
+		"Next rain":                           "Prochaine pluie",
+		"Next snow":                           "Prochaine neige",
+		"Dry":                                 "Sec",
+		"Sunny":                               "Ensoleillé",
+		"Below %d°":                           "Sous %d°",
+		"Above %d°":                           "Au-dessus de %d°",
+		"%d hour(s)":                          "%d heure(s)",
+		"Nothing matched in the next %d days": "Aucune correspondance dans les %d prochains jours",
+		"rain":                                "pluie",
+		"snow":                                "neige",
+		"dry weather":                         "temps sec",
+		"sun":                                 "soleil",
+		"temperatures below %d°":              "températures sous %d°",
+		"temperatures above %d°":              "températures au-dessus de %d°",
+		"No %s in the forecast":               "Pas de %s dans les prévisions",
+		"Alert":                               "Alerte",
+		"Advisory":                            "Avis",
+		"Watch":                               "Veille",
+		"Warning":                             "Avertissement",
+		"Extreme":                             "Extrême",
+		"From %s":                             "À partir de %s",
+		"No active alerts":                    "Aucune alerte active",
+		"Checked %d location(s)":              "%d lieu(x) vérifié(s)",
+		"Copied alert text":                   "Texte de l'alerte copié",
+		"Regions":                             "Régions",
+		"Open this alert in a browser":        "Ouvrir cette alerte dans un navigateur",
+		"Copy the full alert text":            "Copier le texte complet de l'alerte",
+		"Show in Large Type":                  "Afficher en grand",
+		"Rule matched: %s":                    "Règle déclenchée : %s",
+		"%s rejected the API key":             "%s a refusé la clé d'API",
+		"Press Enter to update the key in options":                  "Appuyez sur Entrée pour modifier la clé dans les options",
+		"Manage your API keys in a browser":                         "Gérer vos clés d'API dans un navigateur",
+		"%s request limit reached":                                  "Limite de requêtes de %s atteinte",
+		"Press Enter to change the daily budget in options":         "Appuyez sur Entrée pour modifier le budget quotidien dans les options",
+		"No location found for \"%s\"":                              "Aucun lieu trouvé pour « %s »",
+		"Try a city name, postal code or latitude,longitude":        "Essayez un nom de ville, un code postal ou latitude,longitude",
+		"%s has no forecast for this location":                      "%s n'a pas de prévisions pour ce lieu",
+		"%s is unavailable":                                         "%s est indisponible",
+		"Press Enter to choose another service, or try again later": "Appuyez sur Entrée pour choisir un autre service, ou réessayez plus tard",
+		"%s sent an unreadable response":                            "%s a envoyé une réponse illisible",
+		"The service may have changed; check for a workflow update": "Le service a peut-être changé ; vérifiez les mises à jour du workflow",
+		"Key too short to check":                                    "Clé trop courte pour être vérifiée",
+		"✗ %s rejected this key":                                    "✗ %s a refusé cette clé",
+		"Couldn't check the key: %v":                                "Impossible de vérifier la clé : %v",
+		"✓ key valid":                                               "✓ clé valide",
+		"✓ key valid, %d calls left today":                          "✓ clé valide, %d appels restants aujourd’hui",
+		"Press Enter to open options":                               "Appuyez sur Entrée pour ouvrir les options",
+		"Day":                                                       "Jour",
+		"Conditions":                                                "Conditions",
+		"Low":                                                       "Min.",
+		"High":                                                      "Max.",
+		"Precip":                                                    "Précip.",
+		"Sunrise":                                                   "Lever",
+		"Sunset":                                                    "Coucher",
+		"Time":                                                      "Heure",
+		"Temp":                                                      "Temp.",
+		"Feels":                                                     "Ressenti",
+		"Wind":                                                      "Vent",
+		"Press Enter to remove this rule":                           "Appuyez sur Entrée pour supprimer cette règle",
+		"Add a rule":                                                "Ajouter une règle",
+		"e.g. \"low < 0C tonight\", \"gust > 60km/h tomorrow\", \"precip >= 70\"": "p. ex. « low < 0C tonight », « gust > 60km/h tomorrow », « precip >= 70 »",
+		"Invalid rule": "Règle non valide",
+		"Add rule: %s": "Ajouter la règle : %s",
+		"Matches will be shown in the daily forecast and reported by the watcher": "Les correspondances seront affichées dans les prévisions quotidiennes et signalées par la surveillance",
+		"Forecast conditions to be notified about":                                "Conditions météo à signaler",
+		"Added rule":         "Règle ajoutée",
+		"Removed rule":       "Règle supprimée",
+		"Error saving rules": "Erreur lors de l'enregistrement des règles",
+	},
+	"nl": {
+		"Weather for %s":                  "Weer voor %s",
+		"Currently: %s":                   "Nu: %s",
+		"Today":                           "Vandaag",
+		"Tonight":                         "Vannacht",
+		"Tomorrow":                        "Morgen",
+		"Until %s":                        "Tot %s",
+		" on %s":                          " op %s",
+		"Open this forecast in a browser": "Deze verwachting in een browser openen",
+		"Refreshed!":                      "Vernieuwd!",
+		"Data will be reloaded on the next forecast":              "De gegevens worden bij de volgende verwachting opnieuw geladen",
+		"Add a location, or leave blank for the default location": "Voer een locatie in, of laat leeg voor de standaardlocatie",
+		"Next rain":                           "Volgende regen",
+		"Next snow":                           "Volgende sneeuw",
+		"Dry":                                 "Droog",
+		"Sunny":                               "Zonnig",
+		"Below %d°":                           "Onder %d°",
+		"Above %d°":                           "Boven %d°",
+		"%d hour(s)":                          "%d uur",
+		"Nothing matched in the next %d days": "Geen overeenkomst in de komende %d dagen",
+		"rain":                                "regen",
+		"snow":                                "sneeuw",
+		"dry weather":                         "droog weer",
+		"sun":                                 "zon",
+		"temperatures below %d°":              "temperaturen onder %d°",
+		"temperatures above %d°":              "temperaturen boven %d°",
+		"No %s in the forecast":               "Geen %s in de verwachting",
+		"Alert":                               "Waarschuwing",
+		"Advisory":                            "Advies",
+		"Watch":                               "Voorwaarschuwing",
+		"Warning":                             "Weeralarm",
+		"Extreme":                             "Extreem",
+		"From %s":                             "Vanaf %s",
+		"No active alerts":                    "Geen actieve waarschuwingen",
+		"Checked %d location(s)":              "%d locatie(s) gecontroleerd",
+		"Copied alert text":                   "Waarschuwingstekst gekopieerd",
+		"Regions":                             "Gebieden",
+		"Open this alert in a browser":        "Deze waarschuwing in een browser openen",
+		"Copy the full alert text":            "De volledige waarschuwingstekst kopiëren",
+		"Show in Large Type":                  "In grote letters tonen",
+		"Rule matched: %s":                    "Regel voldaan: %s",
+		"%s rejected the API key":             "%s heeft de API-sleutel geweigerd",
+		"Press Enter to update the key in options":                  "Druk op Enter om de sleutel in de opties te wijzigen",
+		"Manage your API keys in a browser":                         "Je API-sleutels in een browser beheren",
+		"%s request limit reached":                                  "Verzoeklimiet van %s bereikt",
+		"Press Enter to change the daily budget in options":         "Druk op Enter om het dagbudget in de opties te wijzigen",
+		"No location found for \"%s\"":                              "Geen locatie gevonden voor \"%s\"",
+		"Try a city name, postal code or latitude,longitude":        "Probeer een plaatsnaam, postcode of breedtegraad,lengtegraad",
+		"%s has no forecast for this location":                      "%s heeft geen verwachting voor deze locatie",
+		"%s is unavailable":                                         "%s is niet beschikbaar",
+		"Press Enter to choose another service, or try again later": "Druk op Enter om een andere dienst te kiezen, of probeer het later opnieuw",
+		"%s sent an unreadable response":                            "%s stuurde een onleesbaar antwoord",
+		"The service may have changed; check for a workflow update": "De dienst is mogelijk gewijzigd; controleer op een workflow-update",
+		"Key too short to check":                                    "Sleutel te kort om te controleren",
+		"✗ %s rejected this key":                                    "✗ %s heeft deze sleutel geweigerd",
+		"Couldn't check the key: %v":                                "Sleutel kon niet worden gecontroleerd: %v",
+		"✓ key valid":                                               "✓ sleutel geldig",
+		"✓ key valid, %d calls left today":                          "✓ sleutel geldig, nog %d aanvragen vandaag",
+		"Press Enter to open options":                               "Druk op Enter om de opties te openen",
+		"Day":                                                       "Dag",
+		"Conditions":                                                "Weer",
+		"Low":                                                       "Min.",
+		"High":                                                      "Max.",
+		"Precip":                                                    "Neerslag",
+		"Sunrise":                                                   "Zonsopkomst",
+		"Sunset":                                                    "Zonsondergang",
+		"Time":                                                      "Tijd",
+		"Temp":                                                      "Temp.",
+		"Feels":                                                     "Gevoel",
+		"Wind":                                                      "Wind",
+		"Press Enter to remove this rule":                           "Druk op Enter om deze regel te verwijderen",
+		"Add a rule":                                                "Regel toevoegen",
+		"e.g. \"low < 0C tonight\", \"gust > 60km/h tomorrow\", \"precip >= 70\"": "bijv. ‘low < 0C tonight’, ‘gust > 60km/h tomorrow’, ‘precip >= 70’",
+		"Invalid rule": "Ongeldige regel",
+		"Add rule: %s": "Regel toevoegen: %s",
+		"Matches will be shown in the daily forecast and reported by the watcher": "Overeenkomsten worden getoond in de dagverwachting en gemeld door de watcher",
+		"Forecast conditions to be notified about":                                "Weersomstandigheden om over te melden",
+		"Added rule":         "Regel toegevoegd",
+		"Removed rule":       "Regel verwijderd",
+		"Error saving rules": "Fout bij het opslaan van de regels",
+	},
+}
+
+// weekdayNames are the full and abbreviated weekday names for each language,
+// starting with Sunday
+var weekdayNames = map[string][2][7]string{
+	"de": {
+		{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"es": {
+		{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"fr": {
+		{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		{"dim", "lun", "mar", "mer", "jeu", "ven", "sam"},
+	},
+	"nl": {
+		{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+}
+
+// tr returns the translation of a UI string in the configured language,
+// formatted with any given arguments
+func tr(s string, args ...interface{}) string {
+	return config.tr(s, args...)
+}
+
+// tr returns the translation of a UI string in the config's language,
+// formatted with any given arguments
+func (c *configStruct) tr(s string, args ...interface{}) string {
+	if t, ok := uiStrings[c.Language][s]; ok {
+		s = t
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(s, args...)
+	}
+	return s
+}
+
+// weekday returns the full name of the weekday of a time in the configured
+// language
+func weekday(t time.Time) string {
+	return config.weekday(t)
+}
+
+// weekday returns the full name of the weekday of a time in the config's
+// language
+func (c *configStruct) weekday(t time.Time) string {
+	if names, ok := weekdayNames[c.Language]; ok {
+		return names[0][t.Weekday()]
+	}
+	return t.Format("Monday")
+}
+
+// shortWeekday returns the abbreviated name of the weekday of a time in the
+// configured language
+func shortWeekday(t time.Time) string {
+	return config.shortWeekday(t)
+}
+
+// shortWeekday returns the abbreviated name of the weekday of a time in the
+// config's language
+func (c *configStruct) shortWeekday(t time.Time) string {
+	if names, ok := weekdayNames[c.Language]; ok {
+		return names[1][t.Weekday()]
+	}
+	return t.Format("Mon")
+}
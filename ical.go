@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// iCalendar date and UTC date-time formats
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+)
+
+// icalWriter writes iCalendar content lines, folding long lines and using
+// CRLF line endings as RFC 5545 requires
+type icalWriter struct {
+	out *bufio.Writer
+}
+
+// line writes a content line, folding it at 75 octets without splitting
+// UTF-8 sequences
+func (w icalWriter) line(name, value string) {
+	text := name + ":" + value
+	limit := 75
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		w.out.WriteString(text[:cut] + "\r\n ")
+		text = text[cut:]
+		// continuation lines start with a space, which counts toward the limit
+		limit = 74
+	}
+	w.out.WriteString(text + "\r\n")
+}
+
+// icalText escapes a TEXT value
+func icalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icalUID returns a stable unique identifier for an event
+func icalUID(key string) string {
+	return fmt.Sprintf("%x@alfred-weather", sha1.Sum([]byte(key)))
+}
+
+// dailyEventTitle returns the calendar title for a day's forecast
+func dailyEventTitle(d forecast.Daily) string {
+	title := strings.TrimSpace(fmt.Sprintf("%s %s/%s", d.Summary, formatTemperature(d.HighTemp), formatTemperature(d.LowTemp)))
+	if d.Precip > 0 {
+		title += fmt.Sprintf(" ☂ %d%%", d.Precip)
+	}
+	return title
+}
+
+// writeICalendar writes a location's daily forecast as all-day events and
+// its active alerts as timed events
+func writeICalendar(out io.Writer, loc forecast.Location, weather forecast.Weather, now time.Time) error {
+	w := icalWriter{out: bufio.NewWriter(out)}
+	stamp := now.UTC().Format(icalDateTime)
+
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//alfred-weather//Forecast//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", icalText(tr("Weather for %s", loc.ShortName)))
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	w.line("X-PUBLISHED-TTL", "PT1H")
+
+	for _, d := range weather.Daily {
+		var details []string
+		if !d.Sunrise.IsZero() {
+			details = append(details, fmt.Sprintf("☀ %s – %s", d.Sunrise.Format(config.TimeFormat), d.Sunset.Format(config.TimeFormat)))
+		}
+		if d.PrecipAmount > 0 {
+			details = append(details, "☂ "+formatPrecipitation(d.PrecipAmount))
+		}
+		if d.WindSpeed > 0 {
+			details = append(details, "⚑ "+formatSpeed(d.WindSpeed))
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", icalUID(fmt.Sprintf("daily|%s|%s", loc.Name, d.Date.Format(icalDate))))
+		w.line("DTSTAMP", stamp)
+		w.line("DTSTART;VALUE=DATE", d.Date.Format(icalDate))
+		w.line("DTEND;VALUE=DATE", d.Date.AddDate(0, 0, 1).Format(icalDate))
+		w.line("SUMMARY", icalText(dailyEventTitle(d)))
+		if len(details) > 0 {
+			w.line("DESCRIPTION", icalText(strings.Join(details, "\n")))
+		}
+		w.line("LOCATION", icalText(loc.Name))
+		w.line("TRANSP", "TRANSPARENT")
+		w.line("END", "VEVENT")
+	}
+
+	for _, a := range weather.Alerts {
+		if !a.Expires.After(now) {
+			continue
+		}
+
+		start := a.Onset
+		if start.IsZero() {
+			start = now
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", icalUID(alertKey(loc, a)))
+		w.line("DTSTAMP", stamp)
+		w.line("DTSTART", start.UTC().Format(icalDateTime))
+		w.line("DTEND", a.Expires.UTC().Format(icalDateTime))
+		w.line("SUMMARY", icalText("⚠ "+a.Title))
+		if a.Description != "" {
+			w.line("DESCRIPTION", icalText(a.Description))
+		}
+		w.line("LOCATION", icalText(loc.Name))
+		if a.URL != "" {
+			w.line("URL", a.URL)
+		}
+		w.line("CATEGORIES", icalText(tr(a.Severity.String())))
+		w.line("TRANSP", "TRANSPARENT")
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.out.Flush()
+}
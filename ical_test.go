@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+func TestICalLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"short", "Clear", "SUMMARY:Clear\r\n"},
+		{"75 octets", strings.Repeat("a", 67), "SUMMARY:" + strings.Repeat("a", 67) + "\r\n"},
+		{"76 octets", strings.Repeat("a", 68), "SUMMARY:" + strings.Repeat("a", 67) + "\r\n a\r\n"},
+		{
+			// Continuation lines hold 74 octets after the leading space
+			"two folds",
+			strings.Repeat("a", 67+74+1),
+			"SUMMARY:" + strings.Repeat("a", 67) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n",
+		},
+		{
+			// ☂ is 3 octets starting at octet 74, so it moves to the next line
+			"multi-byte at the fold",
+			strings.Repeat("a", 66) + "☂b",
+			"SUMMARY:" + strings.Repeat("a", 66) + "\r\n ☂b\r\n",
+		},
+		{
+			// é is 2 octets ending at octet 75, so it stays on the first line
+			"multi-byte before the fold",
+			strings.Repeat("a", 65) + "éb",
+			"SUMMARY:" + strings.Repeat("a", 65) + "é\r\n b\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := icalWriter{out: bufio.NewWriter(&buf)}
+			w.line("SUMMARY", test.value)
+			w.out.Flush()
+
+			got := buf.String()
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+				if len(line) > 75 {
+					t.Errorf("line %q is %d octets long", line, len(line))
+				}
+			}
+			if unfolded := strings.Replace(got, "\r\n ", "", -1); unfolded != "SUMMARY:"+test.value+"\r\n" {
+				t.Errorf("unfolded line is %q", unfolded)
+			}
+		})
+	}
+}
+
+func TestICalText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Rain, then snow", `Rain\, then snow`},
+		{"High 3°C; low -2°C", `High 3°C\; low -2°C`},
+		{`C:\path`, `C:\\path`},
+		{"line 1\nline 2", `line 1\nline 2`},
+		{"line 1\r\nline 2", `line 1\nline 2`},
+		{`a\,b`, `a\\\,b`},
+	}
+
+	for _, test := range tests {
+		if got := icalText(test.text); got != test.want {
+			t.Errorf("icalText(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestWriteICalendar(t *testing.T) {
+	defer func(c configStruct) { config = c }(config)
+	config = itemConfigs[0].config
+
+	loc := forecast.Location{Name: "Berlin, Germany", ShortName: "Berlin"}
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	now := day.Add(10 * time.Hour)
+
+	var buf bytes.Buffer
+	if err := writeICalendar(&buf, loc, alertWeather(day), now); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	if strings.Count(got, "\n") != strings.Count(got, "\r\n") {
+		t.Error("some lines don't end with CRLF")
+	}
+	for _, want := range []string{
+		"DTSTART;VALUE=DATE:20261014\r\n",
+		"DTEND;VALUE=DATE:20261015\r\n",
+		"SUMMARY:⚠ Winter Storm Warning\r\n",
+		"DTSTART:20261014T180000Z\r\n",
+		"DTEND:20261015T120000Z\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("calendar doesn't contain %q", want)
+		}
+	}
+	// The expired alert isn't included
+	if strings.Contains(got, "Frost Advisory") {
+		t.Error("calendar contains an expired alert")
+	}
+
+	checkGolden(t, "calendar.ics.golden", buf.Bytes())
+}
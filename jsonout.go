@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// jsonSchemaVersion is the version of the JSON forecast document. It changes
+// only when a field is removed or its meaning changes; new fields may be added
+// without changing it. See doc/json.md.
+const jsonSchemaVersion = 1
+
+// jsonForecast is the JSON forecast document. Quantities are always in SI
+// units, regardless of the configured display units.
+type jsonForecast struct {
+	Version   int          `json:"version"`
+	Generated time.Time    `json:"generated"`
+	Provider  jsonProvider `json:"provider"`
+	Location  jsonLocation `json:"location"`
+	Current   jsonCurrent  `json:"current"`
+	Daily     []jsonDaily  `json:"daily"`
+	Hourly    []jsonHourly `json:"hourly"`
+	Alerts    []jsonAlert  `json:"alerts"`
+}
+
+type jsonProvider struct {
+	Name     string `json:"name"`
+	URL      string `json:"url,omitempty"`
+	Language string `json:"language"`
+}
+
+type jsonLocation struct {
+	Name      string  `json:"name"`
+	ShortName string  `json:"short_name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type jsonCurrent struct {
+	Time          time.Time `json:"time"`
+	Summary       string    `json:"summary"`
+	Icon          string    `json:"icon"`
+	TempC         float64   `json:"temp_c"`
+	ApparentTempC float64   `json:"apparent_temp_c"`
+	HumidityPct   float64   `json:"humidity_percent"`
+	WindSpeedMS   float64   `json:"wind_speed_ms"`
+	WindGustMS    float64   `json:"wind_gust_ms"`
+	PressureHPa   float64   `json:"pressure_hpa"`
+	VisibilityKm  float64   `json:"visibility_km"`
+}
+
+type jsonDaily struct {
+	Date           string    `json:"date"`
+	Summary        string    `json:"summary"`
+	Icon           string    `json:"icon"`
+	HighTempC      float64   `json:"high_temp_c"`
+	LowTempC       float64   `json:"low_temp_c"`
+	Sunrise        time.Time `json:"sunrise"`
+	Sunset         time.Time `json:"sunset"`
+	PrecipChance   *int      `json:"precip_chance"`
+	PrecipAmountMm float64   `json:"precip_amount_mm"`
+	WindSpeedMS    float64   `json:"wind_speed_ms"`
+	WindGustMS     float64   `json:"wind_gust_ms"`
+}
+
+type jsonHourly struct {
+	Time           time.Time `json:"time"`
+	Summary        string    `json:"summary"`
+	Icon           string    `json:"icon"`
+	TempC          float64   `json:"temp_c"`
+	ApparentTempC  float64   `json:"apparent_temp_c"`
+	PrecipChance   *int      `json:"precip_chance"`
+	PrecipAmountMm float64   `json:"precip_amount_mm"`
+	WindSpeedMS    float64   `json:"wind_speed_ms"`
+	WindGustMS     float64   `json:"wind_gust_ms"`
+}
+
+type jsonAlert struct {
+	Title       string     `json:"title"`
+	Severity    string     `json:"severity"`
+	Onset       *time.Time `json:"onset"`
+	Expires     time.Time  `json:"expires"`
+	Regions     []string   `json:"regions"`
+	Sender      string     `json:"sender"`
+	Description string     `json:"description"`
+	URL         string     `json:"url,omitempty"`
+}
+
+// jsonSeverities are the severity names used in JSON documents
+var jsonSeverities = map[forecast.Severity]string{
+	forecast.SeverityUnknown:  "unknown",
+	forecast.SeverityAdvisory: "advisory",
+	forecast.SeverityWatch:    "watch",
+	forecast.SeverityWarning:  "warning",
+	forecast.SeverityExtreme:  "extreme",
+}
+
+// precipChance returns a precipitation chance, or nil if it's unknown
+func precipChance(p int) *int {
+	if p < 0 {
+		return nil
+	}
+	return &p
+}
+
+// optionalTime returns a time, or nil if it's unset
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// newJSONForecast builds a JSON forecast document for a location's weather
+func newJSONForecast(loc forecast.Location, w forecast.Weather, now time.Time) jsonForecast {
+	doc := jsonForecast{
+		Version:   jsonSchemaVersion,
+		Generated: now,
+		Provider: jsonProvider{
+			Name:     config.Service,
+			URL:      w.URL,
+			Language: config.Language,
+		},
+		Location: jsonLocation{
+			Name:      loc.Name,
+			ShortName: loc.ShortName,
+			Latitude:  loc.Latitude,
+			Longitude: loc.Longitude,
+		},
+		Current: jsonCurrent{
+			Time:          w.Current.Time,
+			Summary:       w.Current.Summary,
+			Icon:          w.Current.Icon,
+			TempC:         float64(w.Current.Temp),
+			ApparentTempC: float64(w.Current.ApparentTemp),
+			HumidityPct:   w.Current.Humidity,
+			WindSpeedMS:   float64(w.Current.WindSpeed),
+			WindGustMS:    float64(w.Current.WindGust),
+			PressureHPa:   float64(w.Current.Pressure),
+			VisibilityKm:  float64(w.Current.Visibility),
+		},
+		Daily:  []jsonDaily{},
+		Hourly: []jsonHourly{},
+		Alerts: []jsonAlert{},
+	}
+
+	for _, d := range w.Daily {
+		doc.Daily = append(doc.Daily, jsonDaily{
+			Date:           d.Date.Format("2006-01-02"),
+			Summary:        d.Summary,
+			Icon:           d.Icon,
+			HighTempC:      float64(d.HighTemp),
+			LowTempC:       float64(d.LowTemp),
+			Sunrise:        d.Sunrise,
+			Sunset:         d.Sunset,
+			PrecipChance:   precipChance(d.Precip),
+			PrecipAmountMm: float64(d.PrecipAmount),
+			WindSpeedMS:    float64(d.WindSpeed),
+			WindGustMS:     float64(d.WindGust),
+		})
+	}
+
+	for _, h := range w.Hourly {
+		doc.Hourly = append(doc.Hourly, jsonHourly{
+			Time:           h.Time,
+			Summary:        h.Summary,
+			Icon:           h.Icon,
+			TempC:          float64(h.Temp),
+			ApparentTempC:  float64(h.ApparentTemp),
+			PrecipChance:   precipChance(h.Precip),
+			PrecipAmountMm: float64(h.PrecipAmount),
+			WindSpeedMS:    float64(h.WindSpeed),
+			WindGustMS:     float64(h.WindGust),
+		})
+	}
+
+	for _, a := range w.Alerts {
+		if !a.Expires.After(now) {
+			continue
+		}
+		regions := a.Regions
+		if regions == nil {
+			regions = []string{}
+		}
+		doc.Alerts = append(doc.Alerts, jsonAlert{
+			Title:       a.Title,
+			Severity:    jsonSeverities[a.Severity],
+			Onset:       optionalTime(a.Onset),
+			Expires:     a.Expires,
+			Regions:     regions,
+			Sender:      a.Sender,
+			Description: a.Description,
+			URL:         a.URL,
+		})
+	}
+
+	return doc
+}
+
+// writeJSON writes a value as indented JSON
+func writeJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// testWeather returns a forecast with one of each kind of entry
+func testWeather() forecast.Weather {
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	return forecast.Weather{
+		URL: "https://example.com/forecast",
+		Current: forecast.Conditions{
+			Time:         day.Add(9*time.Hour + 55*time.Minute),
+			Summary:      "Light rain",
+			Icon:         "rain",
+			Temp:         -3.7,
+			ApparentTemp: -8.2,
+			Humidity:     87,
+			WindSpeed:    5.5,
+			WindGust:     11,
+			Pressure:     1008,
+			Visibility:   12,
+		},
+		Daily: []forecast.Daily{{
+			Date:         day,
+			Summary:      "Rain in the afternoon",
+			Icon:         "rain",
+			HighTemp:     2.5,
+			LowTemp:      -4.5,
+			Sunrise:      day.Add(7*time.Hour + 21*time.Minute),
+			Sunset:       day.Add(18*time.Hour + 12*time.Minute),
+			Precip:       80,
+			PrecipAmount: 6.4,
+			WindSpeed:    6,
+			WindGust:     14,
+		}},
+		Hourly: []forecast.Hourly{{
+			Time:         day.Add(10 * time.Hour),
+			Summary:      "Light rain",
+			Icon:         "rain",
+			Temp:         -3.5,
+			ApparentTemp: -8,
+			Precip:       -1,
+			PrecipAmount: 0.4,
+			WindSpeed:    5,
+			WindGust:     10,
+		}},
+		Alerts: []forecast.Alert{{
+			Title:       "Winter Storm Warning",
+			Severity:    forecast.SeverityWarning,
+			Expires:     day.Add(36 * time.Hour),
+			Sender:      "NWS",
+			Description: "Heavy snow expected.",
+		}},
+	}
+}
+
+func TestJSONForecast(t *testing.T) {
+	defer func(c configStruct) { config = c }(config)
+	config.Service = serviceOpenWeather
+	config.Language = "en"
+
+	loc := forecast.Location{Name: "Berlin, Germany", ShortName: "Berlin", Latitude: 52.52, Longitude: 13.405}
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, newJSONForecast(loc, testWeather(), now)); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "forecast_v1.json", buf.Bytes())
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/alfred-weather/providers"
+	"github.com/jason0x43/alfred-weather/quota"
+	"github.com/jason0x43/go-alfred"
+)
+
+// serviceKeyLengths are the lengths of each service's API keys. A key isn't
+// checked until it's this long, so that typing a key doesn't use up requests.
+var serviceKeyLengths = map[string]int{
+	serviceDarkSky:     32,
+	serviceOpenWeather: 32,
+	serviceClimaCell:   32,
+}
+
+// keyCheckTTL is how long the result of a key check is reused
+const keyCheckTTL = 24 * time.Hour
+
+// keyChecksFile holds the results of recent key checks
+var keyChecksFile string
+
+// keyCheck is the result of checking a key with its service
+type keyCheck struct {
+	Valid bool
+	Time  time.Time
+}
+
+// checkKey tests an API key with a request to its service. It returns a
+// description of the result, and whether the key may be saved. Keys that are
+// too short or that the service rejected are refused; a key that couldn't be
+// checked, such as when the service is down, may still be saved. The result
+// is remembered for a while, so checking the same key again doesn't make
+// another request.
+func checkKey(service, apiKey string) (desc string, ok bool) {
+	if length, known := serviceKeyLengths[service]; known && len(apiKey) < length {
+		return tr("Key too short to check"), false
+	}
+
+	now := clock.Now()
+	id := quota.Key(service, apiKey)
+
+	checks := map[string]keyCheck{}
+	if err := alfred.LoadJSON(keyChecksFile, &checks); err != nil {
+		dlog.Printf("No key checks loaded: %v", err)
+	}
+
+	check, cached := checks[id]
+	if !cached || now.Sub(check.Time) >= keyCheckTTL || now.Before(check.Time) {
+		var err error
+		if check, err = requestKeyCheck(service, apiKey, now); err != nil {
+			return tr("Couldn't check the key: %v", err), true
+		}
+
+		checks[id] = check
+		for id, c := range checks {
+			if now.Sub(c.Time) >= keyCheckTTL {
+				delete(checks, id)
+			}
+		}
+		if err := alfred.SaveJSON(keyChecksFile, &checks); err != nil {
+			dlog.Printf("Unable to save key checks: %v", err)
+		}
+	}
+
+	if !check.Valid {
+		return tr("✗ %s rejected this key", service), false
+	}
+
+	left := quotas.Usage(service, apiKey, now).Left(requestBudget(service))
+	if left < 0 {
+		return tr("✓ key valid"), true
+	}
+	return tr("✓ key valid, %d calls left today", left), true
+}
+
+// requestKeyCheck asks a service whether it accepts a key. An error means the
+// key couldn't be checked.
+func requestKeyCheck(service, apiKey string, now time.Time) (check keyCheck, err error) {
+	var provider forecast.Provider
+	if provider, err = newProvider(service, apiKey); err != nil {
+		return
+	}
+
+	check.Time = now
+	checker, isChecker := provider.(providers.KeyChecker)
+	if !isChecker {
+		check.Valid = true
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	err = checker.CheckKey(ctx)
+
+	if saveErr := quotas.Save(); saveErr != nil {
+		log.Printf("Error saving usage: %s\n", saveErr)
+	}
+
+	var keyErr *providers.InvalidKeyError
+	if errors.As(err, &keyErr) {
+		return check, nil
+	}
+	if err != nil {
+		return
+	}
+
+	check.Valid = true
+	return
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/alfred-weather/providers"
+)
+
+// fakeKeyChecker is a provider that accepts one key and counts key checks
+type fakeKeyChecker struct {
+	fakeProvider
+	apiKey string
+	valid  string
+	checks *int
+}
+
+func (p *fakeKeyChecker) CheckKey(ctx context.Context) error {
+	*p.checks++
+	if p.apiKey != p.valid {
+		return &providers.InvalidKeyError{Service: serviceOpenWeather}
+	}
+	return nil
+}
+
+func TestCheckKey(t *testing.T) {
+	useFakeServices(t)
+	useClock(t, testNow)
+
+	savedFile, savedProvider := keyChecksFile, newProvider
+	t.Cleanup(func() { keyChecksFile, newProvider = savedFile, savedProvider })
+	keyChecksFile = filepath.Join(t.TempDir(), "key_checks.json")
+
+	valid := strings.Repeat("a", 32)
+	checks := 0
+	newProvider = func(service, apiKey string) (forecast.Provider, error) {
+		return &fakeKeyChecker{apiKey: apiKey, valid: valid, checks: &checks}, nil
+	}
+
+	// Typing a key doesn't check it until it's complete
+	for i := 1; i < len(valid); i++ {
+		if desc, ok := checkKey(serviceOpenWeather, valid[:i]); ok {
+			t.Fatalf("partial key of %d characters was accepted: %s", i, desc)
+		}
+	}
+	if checks != 0 {
+		t.Fatalf("partial keys were checked %d times", checks)
+	}
+
+	tests := []struct {
+		key    string
+		ok     bool
+		desc   string
+		checks int
+	}{
+		{valid, true, "✓ key valid", 1},
+		// the result is remembered
+		{valid, true, "✓ key valid", 1},
+		{strings.Repeat("b", 32), false, "✗ OpenWeather rejected this key", 2},
+		{strings.Repeat("b", 32), false, "✗ OpenWeather rejected this key", 2},
+	}
+	for _, test := range tests {
+		desc, ok := checkKey(serviceOpenWeather, test.key)
+		if ok != test.ok || !strings.HasPrefix(desc, test.desc) {
+			t.Errorf("checkKey(%.4s…) = %q, %v; want %q, %v", test.key, desc, ok, test.desc, test.ok)
+		}
+		if checks != test.checks {
+			t.Errorf("after checking %.4s…, %d requests were made, want %d", test.key, checks, test.checks)
+		}
+	}
+
+	// A day later the key is checked again
+	useClock(t, testNow.Add(keyCheckTTL))
+	checkKey(serviceOpenWeather, valid)
+	if checks != 3 {
+		t.Errorf("remembered result was used after %v", keyCheckTTL)
+	}
+}
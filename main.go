@@ -5,40 +5,88 @@ import (
 	"log"
 	"os"
 	"path"
-	"time"
 
+	"github.com/jason0x43/alfred-weather/cache"
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/alfred-weather/quota"
 	"github.com/jason0x43/go-alfred"
 )
 
 var cacheFile string
 var configFile string
+var usageFile string
 var workflow alfred.Workflow
 
+// configStruct holds the workflow options, which are described in settings
 type configStruct struct {
-	Service        string   `desc:"Service to use"`
-	DarkSkyKey     string   `desc:"Your API key for Dark Sky"`
-	OpenWeatherKey string   `desc:"Your API key for OpenWeather"`
-	ClimaCellKey   string   `desc:"Your API key for ClimaCell"`
-	Icons          string   `desc:"Icon set"`
-	DateFormat     string   `desc:"Date format"`
-	TimeFormat     string   `desc:"Time format"`
-	Location       Location `desc:"Default location"`
-	Units          units    `desc:"Units"`
+	Service        string
+	DarkSkyKey     string
+	OpenWeatherKey string
+	ClimaCellKey   string
+	Icons          string
+	DateFormat     string
+	TimeFormat     string
+	Language       string
+	ShowDecimals   bool
+	DailyBudget    int
+	Location       forecast.Location
+
+	SavedLocations []forecast.Location
+
+	Units           units
+	TemperatureUnit forecast.TemperatureUnit
+	WindUnit        forecast.WindUnit
+	PressureUnit    forecast.PressureUnit
+	PrecipUnit      forecast.PrecipUnit
+	DistanceUnit    forecast.DistanceUnit
 }
 
-var config configStruct
+// units returns the unit settings for each measured quantity
+func (c *configStruct) units() unitConfig {
+	return unitConfig{
+		Temperature: c.TemperatureUnit,
+		Wind:        c.WindUnit,
+		Pressure:    c.PressureUnit,
+		Precip:      c.PrecipUnit,
+		Distance:    c.DistanceUnit,
+	}
+}
 
-var cache struct {
-	Weather Weather
-	Time    time.Time
-	Service string
+// setUnits updates the unit settings for each measured quantity
+func (c *configStruct) setUnits(u unitConfig) {
+	c.TemperatureUnit = u.Temperature
+	c.WindUnit = u.Wind
+	c.PressureUnit = u.Pressure
+	c.PrecipUnit = u.Precip
+	c.DistanceUnit = u.Distance
+	c.Units = u.preset()
 }
 
+var config configStruct
+
+// weatherCache holds recent forecasts for the default and saved locations
+var weatherCache *cache.Store
+
 var dlog = log.New(os.Stderr, "[weather] ", log.LstdFlags)
 
 func main() {
 	var err error
 
+	// Standalone modes are only available outside of Alfred, so that a query
+	// like "watch" can't start one
+	if len(os.Args) > 1 && os.Getenv("alfred_version") == "" {
+		switch os.Args[1] {
+		case "watch":
+			os.Exit(runWatch(os.Args[2:]))
+		case "cli":
+			os.Exit(runCLI(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "mqtt":
+			os.Exit(runPublish(os.Args[2:]))
+		}
+	}
+
 	if workflow, err = alfred.OpenWorkflow(".", true); err != nil {
 		fmt.Printf("Error: %s", err)
 		os.Exit(1)
@@ -46,42 +94,44 @@ func main() {
 
 	workflow.UpdateIcon = "notice.png"
 
-	configFile = path.Join(workflow.DataDir(), "config.json")
-	cacheFile = path.Join(workflow.CacheDir(), "cache.json")
+	initConfig(workflow.DataDir(), workflow.CacheDir())
 
-	dlog.Println("Using config file", configFile)
-	dlog.Println("Using cache file", cacheFile)
-
-	if err := alfred.LoadJSON(configFile, &config); err == nil {
-		dlog.Println("loaded config")
+	commands := []alfred.Command{
+		DailyCommand{},
+		HourlyCommand{},
+		WhenCommand{},
+		AlertsCommand{},
+		OptionsCommand{},
+		RefreshCommand{},
 	}
 
-	if err := alfred.LoadJSON(cacheFile, &cache); err == nil {
-		dlog.Println("loaded cache")
-	}
+	workflow.Run(commands)
+}
 
-	if config.TimeFormat == "" {
-		config.TimeFormat = TimeFormats[0]
-	}
+// initConfig loads the config and cache from the given directories and fills
+// in defaults for any unset options
+func initConfig(dataDir, cacheDir string) {
+	configFile = path.Join(dataDir, "config.json")
+	cacheFile = path.Join(cacheDir, "cache.json")
+	rulesFile = path.Join(dataDir, "rules.json")
+	usageFile = path.Join(dataDir, "usage.json")
+	keyChecksFile = path.Join(cacheDir, "key_checks.json")
 
-	if config.DateFormat == "" {
-		config.DateFormat = DateFormats[0]
-	}
+	dlog.Println("Using config file", configFile)
+	dlog.Println("Using cache file", cacheFile)
 
-	if config.Icons == "" {
-		config.Icons = "grzanka"
+	if err := alfred.LoadJSON(configFile, &config); err == nil {
+		dlog.Println("loaded config")
 	}
 
-	if config.Units == "" {
-		config.Units = unitsUS
+	var err error
+	if weatherCache, err = cache.Open(cacheFile); err != nil {
+		dlog.Printf("Unable to load cache: %v", err)
 	}
 
-	commands := []alfred.Command{
-		DailyCommand{},
-		HourlyCommand{},
-		OptionsCommand{},
-		RefreshCommand{},
+	if quotas, err = quota.Open(usageFile); err != nil {
+		dlog.Printf("Unable to load usage: %v", err)
 	}
 
-	workflow.Run(commands)
+	applyDefaults(&config)
 }
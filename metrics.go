@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// metric is a Prometheus gauge
+type metric struct {
+	name string
+	help string
+	// value returns the metric's value for a location, and whether it's known
+	value func(w *forecast.Weather, now time.Time) (float64, bool)
+}
+
+// weatherMetrics are the metrics exported for each location
+var weatherMetrics = []metric{
+	{
+		name: "weather_temperature_celsius",
+		help: "Current temperature.",
+		value: func(w *forecast.Weather, now time.Time) (float64, bool) {
+			return float64(w.Current.Temp), true
+		},
+	},
+	{
+		name: "weather_apparent_temperature_celsius",
+		help: "Current apparent (feels like) temperature.",
+		value: func(w *forecast.Weather, now time.Time) (float64, bool) {
+			return float64(w.Current.ApparentTemp), true
+		},
+	},
+	{
+		name: "weather_humidity_ratio",
+		help: "Current relative humidity, from 0 to 1.",
+		value: func(w *forecast.Weather, now time.Time) (float64, bool) {
+			return w.Current.Humidity / 100, true
+		},
+	},
+	{
+		name: "weather_precipitation_probability_ratio",
+		help: "Chance of precipitation in the coming hour, from 0 to 1.",
+		value: func(w *forecast.Weather, now time.Time) (float64, bool) {
+			chance := -1
+			for _, h := range w.Hourly {
+				if h.Time.Add(time.Hour).After(now) {
+					chance = h.Precip
+					break
+				}
+			}
+			if chance < 0 && len(w.Daily) > 0 {
+				chance = w.Daily[0].Precip
+			}
+			return float64(chance) / 100, chance >= 0
+		},
+	},
+	{
+		name: "weather_alerts_active",
+		help: "Number of active weather alerts.",
+		value: func(w *forecast.Weather, now time.Time) (float64, bool) {
+			count := 0
+			for _, a := range w.Alerts {
+				if a.Expires.After(now) {
+					count++
+				}
+			}
+			return float64(count), true
+		},
+	},
+}
+
+// metricLabels returns the label set for a location
+func metricLabels(loc forecast.Location) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return fmt.Sprintf(`{location="%s",provider="%s"}`, escape.Replace(loc.Name), escape.Replace(config.Service))
+}
+
+// writeMetrics writes metrics for the default and saved locations in the
+// Prometheus text format. Forecasts come from the cache when possible, so
+// frequent scrapes don't cause extra API requests.
+func (s *forecastServer) writeMetrics(out io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clock.Now()
+	locations := allLocations()
+	forecasts := map[string]*forecast.Weather{}
+
+	fmt.Fprintf(out, "# HELP weather_up Whether the forecast for a location could be retrieved.\n")
+	fmt.Fprintf(out, "# TYPE weather_up gauge\n")
+	for _, loc := range locations {
+		up := 0
+		if weather, err := s.forecast(loc, now); err != nil {
+			dlog.Printf("Unable to get forecast for %s: %v", loc.Name, err)
+		} else {
+			forecasts[loc.Name] = &weather
+			up = 1
+		}
+		fmt.Fprintf(out, "weather_up%s %d\n", metricLabels(loc), up)
+	}
+
+	for _, m := range weatherMetrics {
+		fmt.Fprintf(out, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(out, "# TYPE %s gauge\n", m.name)
+		for _, loc := range locations {
+			weather, ok := forecasts[loc.Name]
+			if !ok {
+				continue
+			}
+			if value, ok := m.value(weather, now); ok {
+				fmt.Fprintf(out, "%s%s %g\n", m.name, metricLabels(loc), value)
+			}
+		}
+	}
+}
+
+// handleMetrics serves the Prometheus metrics endpoint
+func (s *forecastServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if err := validateConfig(); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.writeMetrics(w)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttDisconnect = 0xe0
+)
+
+// mqttConnackErrors describes the CONNACK return codes
+var mqttConnackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// mqttClient is a minimal MQTT 3.1.1 client that can only publish messages
+// at QoS 0
+type mqttClient struct {
+	conn net.Conn
+	out  *bufio.Writer
+}
+
+// mqttOptions are the settings for an MQTT connection
+type mqttOptions struct {
+	// Broker is a URL like tcp://host:1883 or ssl://host:8883
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+}
+
+// dialMQTT connects to an MQTT broker
+func dialMQTT(opts mqttOptions) (c *mqttClient, err error) {
+	var u *url.URL
+	if u, err = url.Parse(opts.Broker); err != nil {
+		return
+	}
+
+	host := u.Host
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	var conn net.Conn
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		if u.Port() == "" {
+			host = net.JoinHostPort(host, "1883")
+		}
+		conn, err = dialer.Dial("tcp", host)
+	case "ssl", "tls", "mqtts":
+		if u.Port() == "" {
+			host = net.JoinHostPort(host, "8883")
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("Unsupported broker URL %q", opts.Broker)
+	}
+	if err != nil {
+		return
+	}
+
+	c = &mqttClient{conn: conn, out: bufio.NewWriter(conn)}
+	if err = c.connect(opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return
+}
+
+// connect sends a CONNECT packet and waits for the broker's CONNACK
+func (c *mqttClient) connect(opts mqttOptions) (err error) {
+	var body bytes.Buffer
+	writeMQTTString(&body, "MQTT")
+	body.WriteByte(4) // protocol level 3.1.1
+
+	flags := byte(0x02) // clean session
+	if opts.Username != "" {
+		flags |= 0x80
+		if opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body.WriteByte(flags)
+	body.Write([]byte{0, 60}) // keep alive, in seconds
+
+	writeMQTTString(&body, opts.ClientID)
+	if opts.Username != "" {
+		writeMQTTString(&body, opts.Username)
+		if opts.Password != "" {
+			writeMQTTString(&body, opts.Password)
+		}
+	}
+
+	if err = c.send(mqttConnect, body.Bytes()); err != nil {
+		return
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetReadDeadline(time.Time{})
+
+	ack := make([]byte, 4)
+	if _, err = io.ReadFull(c.conn, ack); err != nil {
+		return fmt.Errorf("Unable to read CONNACK: %v", err)
+	}
+	if ack[0] != mqttConnack || ack[1] != 2 {
+		return fmt.Errorf("Unexpected response from broker")
+	}
+	if ack[3] != 0 {
+		if msg, ok := mqttConnackErrors[ack[3]]; ok {
+			return fmt.Errorf("Broker refused connection: %s", msg)
+		}
+		return fmt.Errorf("Broker refused connection: code %d", ack[3])
+	}
+
+	return
+}
+
+// Publish sends a message at QoS 0
+func (c *mqttClient) Publish(topic string, payload []byte, retain bool) error {
+	var body bytes.Buffer
+	writeMQTTString(&body, topic)
+	body.Write(payload)
+
+	header := byte(mqttPublish)
+	if retain {
+		header |= 0x01
+	}
+	return c.send(header, body.Bytes())
+}
+
+// Close disconnects from the broker
+func (c *mqttClient) Close() error {
+	c.send(mqttDisconnect, nil)
+	return c.conn.Close()
+}
+
+// send writes a packet with a fixed header
+func (c *mqttClient) send(header byte, body []byte) (err error) {
+	c.out.WriteByte(header)
+
+	// The remaining length is encoded 7 bits at a time
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		c.out.WriteByte(b)
+		if n == 0 {
+			break
+		}
+	}
+
+	c.out.Write(body)
+
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetWriteDeadline(time.Time{})
+	return c.out.Flush()
+}
+
+// writeMQTTString writes a length-prefixed UTF-8 string
+func writeMQTTString(buf *bytes.Buffer, s string) {
+	buf.WriteByte(byte(len(s) >> 8))
+	buf.WriteByte(byte(len(s)))
+	buf.WriteString(s)
+}
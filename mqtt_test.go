@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// mqttPacket is a control packet received by the fake broker
+type mqttPacket struct {
+	header byte
+	body   []byte
+}
+
+// readMQTTPacket reads a packet, decoding the variable-length remaining
+// length
+func readMQTTPacket(r *bufio.Reader) (p mqttPacket, err error) {
+	if p.header, err = r.ReadByte(); err != nil {
+		return
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return p, fmt.Errorf("remaining length is too long")
+		}
+		var b byte
+		if b, err = r.ReadByte(); err != nil {
+			return
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+
+	p.body = make([]byte, length)
+	_, err = io.ReadFull(r, p.body)
+	return
+}
+
+// readMQTTString reads a length-prefixed string from the front of a packet
+// body, returning the rest of the body
+func readMQTTString(body []byte) (s string, rest []byte, err error) {
+	if len(body) < 2 {
+		return "", nil, fmt.Errorf("string length is missing")
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+n {
+		return "", nil, fmt.Errorf("string is truncated")
+	}
+	return string(body[2 : 2+n]), body[2+n:], nil
+}
+
+// fakeBroker accepts one connection, answers its CONNECT with returnCode and
+// sends every packet it receives on packets
+func fakeBroker(t *testing.T, returnCode byte) (addr string, packets chan mqttPacket) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	packets = make(chan mqttPacket, 10)
+	go func() {
+		defer close(packets)
+
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			p, err := readMQTTPacket(r)
+			if err != nil {
+				return
+			}
+			packets <- p
+			if p.header == mqttConnect {
+				conn.Write([]byte{mqttConnack, 2, 0, returnCode})
+			}
+		}
+	}()
+
+	return "tcp://" + ln.Addr().String(), packets
+}
+
+func TestMQTTPublish(t *testing.T) {
+	broker, packets := fakeBroker(t, 0)
+
+	c, err := dialMQTT(mqttOptions{
+		Broker:   broker,
+		ClientID: "alfred-weather-test",
+		Username: "user",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Long enough that the remaining length takes two bytes
+	long := []byte(strings.Repeat("x", 300))
+	if err := c.Publish("weather/current", []byte(`{"temp":20}`), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Publish("weather/forecast", long, false); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	connect := <-packets
+	if connect.header != mqttConnect {
+		t.Fatalf("first packet = %#x, want CONNECT", connect.header)
+	}
+	protocol, rest, err := readMQTTString(connect.body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protocol != "MQTT" || rest[0] != 4 {
+		t.Errorf("protocol = %q level %d, want MQTT level 4", protocol, rest[0])
+	}
+	if flags := rest[1]; flags != 0xc2 {
+		t.Errorf("connect flags = %#x, want 0xc2", flags)
+	}
+	rest = rest[4:]
+	for _, want := range []string{"alfred-weather-test", "user", "secret"} {
+		var got string
+		if got, rest, err = readMQTTString(rest); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("connect payload field = %q, want %q", got, want)
+		}
+	}
+
+	tests := []struct {
+		header  byte
+		topic   string
+		payload []byte
+	}{
+		{mqttPublish | 0x01, "weather/current", []byte(`{"temp":20}`)},
+		{mqttPublish, "weather/forecast", long},
+	}
+	for _, test := range tests {
+		p := <-packets
+		if p.header != test.header {
+			t.Errorf("%s: header = %#x, want %#x", test.topic, p.header, test.header)
+		}
+		topic, payload, err := readMQTTString(p.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if topic != test.topic || !bytes.Equal(payload, test.payload) {
+			t.Errorf("publish = %q with %d bytes, want %q with %d bytes", topic, len(payload), test.topic, len(test.payload))
+		}
+	}
+
+	if p := <-packets; p.header != mqttDisconnect || len(p.body) != 0 {
+		t.Errorf("last packet = %#x with %d bytes, want an empty DISCONNECT", p.header, len(p.body))
+	}
+}
+
+func TestMQTTConnectRefused(t *testing.T) {
+	broker, _ := fakeBroker(t, 5)
+
+	_, err := dialMQTT(mqttOptions{Broker: broker, ClientID: "alfred-weather-test"})
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("error = %v, want a not authorized error", err)
+	}
+}
@@ -3,14 +3,10 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"os"
-	"path"
-	"reflect"
-	"strconv"
-	"time"
 
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/alfred-weather/geocoding"
 	"github.com/jason0x43/go-alfred"
 )
 
@@ -28,220 +24,31 @@ func (c OptionsCommand) About() alfred.CommandDef {
 
 // Items ...
 func (c OptionsCommand) Items(arg, data string) (items []alfred.Item, err error) {
-	ct := reflect.TypeOf(config)
-	cfg := reflect.Indirect(reflect.ValueOf(config))
+	name, value := alfred.SplitCmd(arg)
 
-	for i := 0; i < ct.NumField(); i++ {
-		field := ct.Field(i)
-		desc := field.Tag.Get("desc")
-		if desc == "" {
-			continue
-		}
+	if name == "Rules" {
+		return ruleOptionItems(value)
+	}
 
-		name, value := alfred.SplitCmd(arg)
-		if !alfred.FuzzyMatches(field.Name, name) {
+	for i := range settings {
+		s := &settings[i]
+		if !s.applies(&config) || !alfred.FuzzyMatches(s.name, name) {
 			continue
 		}
 
-		switch field.Name {
-		case "Service":
-			if name == "Service" {
-				if alfred.FuzzyMatches(string(serviceDarkSky), value) {
-					items = append(items, makeStringChoice("Service", string(serviceDarkSky)))
-				}
-
-				if alfred.FuzzyMatches(string(serviceOpenWeather), value) {
-					items = append(items, makeStringChoice("Service", string(serviceOpenWeather)))
-				}
-
-				if alfred.FuzzyMatches(string(serviceClimaCell), value) {
-					items = append(items, makeStringChoice("Service", string(serviceClimaCell)))
-				}
-
-				return
-			}
-
-			items = append(items, alfred.Item{
-				Title:        fmt.Sprintf("Service: %v", config.Service),
-				Autocomplete: "Service ",
-				Subtitle:     desc,
-			})
-
-		case "Units":
-			if name == "Units" {
-				if alfred.FuzzyMatches(string(unitsMetric), value) {
-					items = append(items, makeStringChoice("Units", string(unitsMetric)))
-				}
-
-				if alfred.FuzzyMatches(string(unitsUS), value) {
-					items = append(items, makeStringChoice("Units", string(unitsUS)))
-				}
-
-				return
-			}
-
-			items = append(items, alfred.Item{
-				Title:        fmt.Sprintf("Units: %v", config.Units),
-				Autocomplete: "Units ",
-				Subtitle:     desc,
-			})
-
-		case "Location":
-			if name == "Location" {
-				if value == "" {
-					items = append(items, alfred.Item{
-						Title:    "Location: " + config.Location.Name,
-						Subtitle: "Enter a new city/state or ZIP",
-					})
-				} else {
-					var locations []Geocode
-					if locations, err = Locate(value); err != nil {
-						return
-					}
-
-					for _, loc := range locations {
-						opts := config
-						o := reflect.Indirect(reflect.ValueOf(&opts))
-						o.FieldByName("Location").Set(reflect.ValueOf(loc.Location()))
-
-						items = append(items, alfred.Item{
-							Title:    loc.Name,
-							Subtitle: fmt.Sprintf("(%f, %f)", loc.Latitude, loc.Longitude),
-							Arg: &alfred.ItemArg{
-								Keyword: "options",
-								Mode:    alfred.ModeDo,
-								Data:    alfred.Stringify(&opts),
-							},
-						})
-					}
-				}
-
-				return
-			}
-
-			items = append(items, alfred.Item{
-				Title:        "Location: " + config.Location.Name,
-				Subtitle:     desc,
-				Autocomplete: "Location ",
-			})
-
-		case "Icons":
-			if name == "Icons" {
-				var dirs []os.FileInfo
-				if dirs, err = ioutil.ReadDir("icons"); err != nil {
-					return
-				}
-				for _, dir := range dirs {
-					if dir.IsDir() && alfred.FuzzyMatches(dir.Name(), value) {
-						items = append(items, makeIconChoice("Icons", dir.Name()))
-					}
-				}
-				return
-			}
-
-			items = append(items, alfred.Item{
-				Title:        "Icons: " + config.Icons,
-				Subtitle:     desc,
-				Autocomplete: "Icons ",
-			})
-
-		case "DateFormat":
-			if name == "DateFormat" {
-				for _, tf := range DateFormats {
-					items = append(items, makeStringChoice("DateFormat", tf))
-				}
-				return
-			}
-
-			items = append(items, alfred.Item{
-				Title:        "DateFormat: " + config.DateFormat,
-				Subtitle:     desc,
-				Autocomplete: "DateFormat ",
-			})
-
-		case "TimeFormat":
-			if name == "TimeFormat" {
-				for _, tf := range TimeFormats {
-					items = append(items, makeStringChoice("TimeFormat", tf))
-				}
-				return
-			}
+		if name == s.name {
+			return s.valueItems(value)
+		}
 
-			items = append(items, alfred.Item{
-				Title:        "TimeFormat: " + config.TimeFormat,
-				Subtitle:     desc,
-				Autocomplete: "TimeFormat ",
-			})
+		items = append(items, s.item())
+	}
 
-		default:
-			item := alfred.Item{
-				Title:        field.Name,
-				Subtitle:     desc,
-				Autocomplete: field.Name,
-			}
+	if config.Service != "" && alfred.FuzzyMatches("Usage", name) {
+		items = append(items, usageItem())
+	}
 
-			itemArg := &alfred.ItemArg{
-				Keyword: "options",
-				Mode:    alfred.ModeDo,
-			}
-
-			switch field.Type.Name() {
-			case "bool":
-				f := cfg.FieldByName(field.Name)
-				if name == field.Name {
-					item.Title += " (press Enter to toggle)"
-				}
-
-				// copy the current options, update them, and use as the arg
-				opts := config
-				o := reflect.Indirect(reflect.ValueOf(&opts))
-				newVal := !f.Bool()
-				o.FieldByName(field.Name).SetBool(newVal)
-				item.Arg = itemArg
-				item.Arg.Data = alfred.Stringify(&opts)
-				item.AddCheckBox(f.Bool())
-
-			case "int":
-				item.Autocomplete += " "
-
-				if value != "" {
-					val, err := strconv.Atoi(value)
-					if err != nil {
-						return items, err
-					}
-					item.Title += fmt.Sprintf(": %d", val)
-
-					// copy the current options, update them, and use as the arg
-					opts := config
-					o := reflect.Indirect(reflect.ValueOf(&opts))
-					o.FieldByName(field.Name).SetInt(int64(val))
-					item.Arg = itemArg
-					item.Arg.Data = alfred.Stringify(opts)
-				} else {
-					f := cfg.FieldByName(field.Name)
-					val := f.Int()
-					item.Title += fmt.Sprintf(": %v", val)
-					if name == field.Name {
-						item.Title += " (type a new value to change)"
-					}
-				}
-
-			case "string":
-				f := cfg.FieldByName(field.Name)
-				item.Autocomplete += " "
-				item.Title += ": " + f.String()
-
-				if name == field.Name {
-					opts := config
-					o := reflect.Indirect(reflect.ValueOf(&opts))
-					o.FieldByName(field.Name).SetString(value)
-					item.Arg = itemArg
-					item.Arg.Data = alfred.Stringify(&opts)
-				}
-			}
-
-			items = append(items, item)
-		}
+	if alfred.FuzzyMatches("Rules", name) {
+		items = append(items, rulesItem())
 	}
 
 	alfred.FuzzySort(items, arg)
@@ -251,43 +58,114 @@ func (c OptionsCommand) Items(arg, data string) (items []alfred.Item, err error)
 
 // Do ...
 func (c OptionsCommand) Do(data string) (out string, err error) {
+	var opts optionsCfg
+	if json.Unmarshal([]byte(data), &opts) == nil && opts.Rules != nil {
+		return updateRules(*opts.Rules)
+	}
+
 	if err = json.Unmarshal([]byte(data), &config); err != nil {
 		return
 	}
 
+	// Keep the units preset in sync with the individual unit settings
+	config.Units = config.units().preset()
+
 	if err = alfred.SaveJSON(configFile, &config); err != nil {
 		log.Printf("Error saving config: %s\n", err)
 		return "Error updating config", err
 	}
 
 	// Clear the cache to allow data to be requestsed with the new options
-	cache.Time = time.Unix(0, 0)
-	if err = alfred.SaveJSON(cacheFile, &cache); err != nil {
+	weatherCache.Clear()
+	if err = weatherCache.Save(); err != nil {
 		log.Printf("Error saving cache: %s\n", err)
 	}
 
 	return "Updated config", err
 }
 
-func makeStringChoice(fieldName, value string) alfred.Item {
-	opts := config
-	o := reflect.Indirect(reflect.ValueOf(&opts))
-	currentValue := o.FieldByName(fieldName).String()
-	o.FieldByName(fieldName).SetString(value)
-	item := alfred.Item{
-		Title: value,
-		Arg: &alfred.ItemArg{
-			Keyword: "options",
-			Mode:    alfred.ModeDo,
-			Data:    alfred.Stringify(&opts),
-		},
+// locationItems returns the items for choosing the default location
+func locationItems(s *setting, value string) (items []alfred.Item, err error) {
+	if value == "" {
+		return []alfred.Item{{
+			Title:    "Location: " + config.Location.Name,
+			Subtitle: "Enter a new city/state or ZIP",
+		}}, nil
+	}
+
+	var locations []geocoding.Geocode
+	if locations, err = locateGeocodes(value); err != nil {
+		return
+	}
+
+	for _, loc := range locations {
+		opts := config
+		opts.Location = loc.Location()
+
+		items = append(items, alfred.Item{
+			Title:    loc.Name,
+			Subtitle: fmt.Sprintf("(%f, %f)", loc.Latitude, loc.Longitude),
+			Arg: &alfred.ItemArg{
+				Keyword: "options",
+				Mode:    alfred.ModeDo,
+				Data:    alfred.Stringify(&opts),
+			},
+		})
+	}
+
+	return
+}
+
+// savedLocationItems returns the items for removing a saved location or, once
+// a name is entered, adding one
+func savedLocationItems(s *setting, value string) (items []alfred.Item, err error) {
+	if value == "" {
+		for i, loc := range config.SavedLocations {
+			opts := config
+			opts.SavedLocations = append(append([]forecast.Location{}, config.SavedLocations[:i]...), config.SavedLocations[i+1:]...)
+
+			items = append(items, alfred.Item{
+				Title:    loc.Name,
+				Subtitle: "Press Enter to remove this location",
+				Arg: &alfred.ItemArg{
+					Keyword: "options",
+					Mode:    alfred.ModeDo,
+					Data:    alfred.Stringify(&opts),
+				},
+			})
+		}
+
+		items = append(items, alfred.Item{
+			Title:    "Add a location",
+			Subtitle: "Enter a city/state or ZIP",
+		})
+		return
 	}
-	item.AddCheckBox(currentValue == value)
-	return item
+
+	var locations []geocoding.Geocode
+	if locations, err = locateGeocodes(value); err != nil {
+		return
+	}
+
+	for _, loc := range locations {
+		opts := config
+		opts.SavedLocations = append(append([]forecast.Location{}, config.SavedLocations...), loc.Location())
+
+		items = append(items, alfred.Item{
+			Title:    loc.Name,
+			Subtitle: fmt.Sprintf("Add (%f, %f) to the saved locations", loc.Latitude, loc.Longitude),
+			Arg: &alfred.ItemArg{
+				Keyword: "options",
+				Mode:    alfred.ModeDo,
+				Data:    alfred.Stringify(&opts),
+			},
+		})
+	}
+
+	return
 }
 
-func makeIconChoice(fieldName, value string) alfred.Item {
-	item := makeStringChoice(fieldName, value)
-	item.Icon = path.Join("icons", value, "tstorms.png")
-	return item
+// optionsCfg is the data for options that aren't part of the config
+type optionsCfg struct {
+	Rules *rulesCfg `json:",omitempty"`
 }
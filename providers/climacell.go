@@ -0,0 +1,494 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/fetch"
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+var ccIconNames = map[string]string{
+	"clear":               "clear",
+	"mostly_clear":        "mostlysunny",
+	"partly_cloudy":       "partlycloudy",
+	"mostly_cloudy":       "mostlycloudy",
+	"cloudy":              "cloudy",
+	"fog":                 "fog",
+	"fog_light":           "hazy",
+	"drizzle":             "rain",
+	"rain_light":          "rain",
+	"rain":                "rain",
+	"rain_heavy":          "rain",
+	"tstorm":              "tstorms",
+	"flurries":            "flurries",
+	"snow_light":          "snow",
+	"snow":                "snow",
+	"snow_heavy":          "snow",
+	"ice_pellets_light":   "sleet",
+	"ice_pellets":         "sleet",
+	"ice_pellets_heavy":   "sleet",
+	"freezing_drizzle":    "sleet",
+	"freezing_rain_light": "sleet",
+	"freezing_rain":       "sleet",
+	"freezing_rain_heavy": "sleet",
+}
+
+var ccDescriptions = map[string]string{
+	"clear":               "Clear",
+	"mostly_clear":        "Mostly sunny",
+	"partly_cloudy":       "Partly cloudy",
+	"mostly_cloudy":       "Mostly cloudy",
+	"cloudy":              "Cloudy",
+	"fog":                 "Fog",
+	"fog_light":           "Light fog",
+	"drizzle":             "Drizzle",
+	"rain_light":          "Light rain",
+	"rain":                "Rain",
+	"rain_heavy":          "Heavy rain",
+	"tstorm":              "Thunderstorms",
+	"flurries":            "Flurries",
+	"snow_light":          "Light snow",
+	"snow":                "Snow",
+	"snow_heavy":          "Heavy snow",
+	"ice_pellets_light":   "Light sleet",
+	"ice_pellets":         "Sleet",
+	"ice_pellets_heavy":   "Heavy sleet",
+	"freezing_drizzle":    "Freezing drizzle",
+	"freezing_rain_light": "Light freezing rain",
+	"freezing_rain":       "Freezing rain",
+	"freezing_rain_heavy": "Heavy freezing rain",
+}
+
+// ccDescriptionTranslations are translations of ccDescriptions, keyed by
+// language and then by weather code
+var ccDescriptionTranslations = map[string]map[string]string{
+	"de": {
+		"clear":               "Klar",
+		"mostly_clear":        "Überwiegend sonnig",
+		"partly_cloudy":       "Teilweise bewölkt",
+		"mostly_cloudy":       "Überwiegend bewölkt",
+		"cloudy":              "Bewölkt",
+		"fog":                 "Nebel",
+		"fog_light":           "Leichter Nebel",
+		"drizzle":             "Nieselregen",
+		"rain_light":          "Leichter Regen",
+		"rain":                "Regen",
+		"rain_heavy":          "Starker Regen",
+		"tstorm":              "Gewitter",
+		"flurries":            "Schneegestöber",
+		"snow_light":          "Leichter Schneefall",
+		"snow":                "Schneefall",
+		"snow_heavy":          "Starker Schneefall",
+		"ice_pellets_light":   "Leichter Graupel",
+		"ice_pellets":         "Graupel",
+		"ice_pellets_heavy":   "Starker Graupel",
+		"freezing_drizzle":    "Gefrierender Nieselregen",
+		"freezing_rain_light": "Leichter gefrierender Regen",
+		"freezing_rain":       "Gefrierender Regen",
+		"freezing_rain_heavy": "Starker gefrierender Regen",
+	},
+	"es": {
+		"clear":               "Despejado",
+		"mostly_clear":        "Mayormente soleado",
+		"partly_cloudy":       "Parcialmente nublado",
+		"mostly_cloudy":       "Mayormente nublado",
+		"cloudy":              "Nublado",
+		"fog":                 "Niebla",
+		"fog_light":           "Niebla ligera",
+		"drizzle":             "Llovizna",
+		"rain_light":          "Lluvia ligera",
+		"rain":                "Lluvia",
+		"rain_heavy":          "Lluvia intensa",
+		"tstorm":              "Tormentas",
+		"flurries":            "Copos de nieve",
+		"snow_light":          "Nevada ligera",
+		"snow":                "Nieve",
+		"snow_heavy":          "Nevada intensa",
+		"ice_pellets_light":   "Aguanieve ligera",
+		"ice_pellets":         "Aguanieve",
+		"ice_pellets_heavy":   "Aguanieve intensa",
+		"freezing_drizzle":    "Llovizna helada",
+		"freezing_rain_light": "Lluvia helada ligera",
+		"freezing_rain":       "Lluvia helada",
+		"freezing_rain_heavy": "Lluvia helada intensa",
+	},
+	"fr": {
+		"clear":               "Dégagé",
+		"mostly_clear":        "Plutôt ensoleillé",
+		"partly_cloudy":       "Partiellement nuageux",
+		"mostly_cloudy":       "Plutôt nuageux",
+		"cloudy":              "Nuageux",
+		"fog":                 "Brouillard",
+		"fog_light":           "Brume",
+		"drizzle":             "Bruine",
+		"rain_light":          "Pluie faible",
+		"rain":                "Pluie",
+		"rain_heavy":          "Forte pluie",
+		"tstorm":              "Orages",
+		"flurries":            "Averses de neige",
+		"snow_light":          "Neige faible",
+		"snow":                "Neige",
+		"snow_heavy":          "Fortes chutes de neige",
+		"ice_pellets_light":   "Grésil faible",
+		"ice_pellets":         "Grésil",
+		"ice_pellets_heavy":   "Fort grésil",
+		"freezing_drizzle":    "Bruine verglaçante",
+		"freezing_rain_light": "Pluie verglaçante faible",
+		"freezing_rain":       "Pluie verglaçante",
+		"freezing_rain_heavy": "Forte pluie verglaçante",
+	},
+	"nl": {
+		"clear":               "Helder",
+		"mostly_clear":        "Overwegend zonnig",
+		"partly_cloudy":       "Gedeeltelijk bewolkt",
+		"mostly_cloudy":       "Overwegend bewolkt",
+		"cloudy":              "Bewolkt",
+		"fog":                 "Mist",
+		"fog_light":           "Lichte mist",
+		"drizzle":             "Motregen",
+		"rain_light":          "Lichte regen",
+		"rain":                "Regen",
+		"rain_heavy":          "Zware regen",
+		"tstorm":              "Onweer",
+		"flurries":            "Sneeuwvlokken",
+		"snow_light":          "Lichte sneeuw",
+		"snow":                "Sneeuw",
+		"snow_heavy":          "Zware sneeuw",
+		"ice_pellets_light":   "Lichte natte sneeuw",
+		"ice_pellets":         "Natte sneeuw",
+		"ice_pellets_heavy":   "Zware natte sneeuw",
+		"freezing_drizzle":    "IJzel motregen",
+		"freezing_rain_light": "Lichte ijzel",
+		"freezing_rain":       "IJzel",
+		"freezing_rain_heavy": "Zware ijzel",
+	},
+}
+
+const ccAPI = "https://api.climacell.co/v3/weather"
+
+const ccUnits = "si"
+
+const ccEventsAPI = "https://api.tomorrow.io/v4/events"
+
+// ccInsights are the event categories requested from the events API
+const ccInsights = "fires,wind,winter,thunderstorms,floods,temperature,tropical,marine,fog,tornado"
+
+// ClimaCell is a weather service handle
+type ClimaCell struct {
+	options Options
+}
+
+type ccFloatValue struct {
+	Value float64 `json:"value"`
+	Units string  `json:"units"`
+}
+
+type ccIntValue struct {
+	Value int    `json:"value"`
+	Units string `json:"units"`
+}
+
+type ccStringValue struct {
+	Value string `json:"value"`
+}
+
+type ccCurrent struct {
+	Temp         ccFloatValue  `json:"temp"`
+	ApparentTemp ccFloatValue  `json:"feels_like"`
+	Humidity     ccFloatValue  `json:"humidity"`
+	WindSpeed    ccFloatValue  `json:"wind_speed"`
+	WindGust     ccFloatValue  `json:"wind_gust"`
+	Pressure     ccFloatValue  `json:"baro_pressure"`
+	Visibility   ccFloatValue  `json:"visibility"`
+	WeatherCode  ccStringValue `json:"weather_code"`
+	Time         ccStringValue `json:"observation_time"`
+}
+
+// ccRange is a daily minimum and maximum. The service sends each field as a
+// list of ranges, where a range may hold only one of the two values.
+type ccRange struct {
+	Time string       `json:"observation_time"`
+	Min  ccFloatValue `json:"min"`
+	Max  ccFloatValue `json:"max"`
+}
+
+type ccDaily struct {
+	Temp              []ccRange     `json:"temp"`
+	PrecipProbability ccIntValue    `json:"precipitation_probability"`
+	ApparentTemp      []ccRange     `json:"feels_like"`
+	WindSpeed         []ccRange     `json:"wind_speed"`
+	PrecipAmount      ccFloatValue  `json:"precipitation_accumulation"`
+	SunriseTime       ccStringValue `json:"sunrise"`
+	SunsetTime        ccStringValue `json:"sunset"`
+	Date              ccStringValue `json:"observation_time"`
+	WeatherCode       ccStringValue `json:"weather_code"`
+}
+
+type ccHourly struct {
+	Temp              ccFloatValue  `json:"temp"`
+	ApparentTemp      ccFloatValue  `json:"feels_like"`
+	PrecipProbability ccIntValue    `json:"precipitation_probability"`
+	PrecipAmount      ccFloatValue  `json:"precipitation"`
+	WindSpeed         ccFloatValue  `json:"wind_speed"`
+	WindGust          ccFloatValue  `json:"wind_gust"`
+	Time              ccStringValue `json:"observation_time"`
+	WeatherCode       ccStringValue `json:"weather_code"`
+}
+
+type ccEvent struct {
+	Insight     string `json:"insight"`
+	StartTime   string `json:"startTime"`
+	EndTime     string `json:"endTime"`
+	Severity    string `json:"severity"`
+	EventValues struct {
+		Origin      string `json:"origin"`
+		Title       string `json:"title"`
+		Headline    string `json:"headline"`
+		Description string `json:"description"`
+		Instruction string `json:"instruction"`
+		AreaDesc    string `json:"areaDesc"`
+	} `json:"eventValues"`
+}
+
+type ccEvents struct {
+	Data struct {
+		Events []ccEvent `json:"events"`
+	} `json:"data"`
+}
+
+// NewClimaCell returns a new ClimaCell handle
+func NewClimaCell(options Options) *ClimaCell {
+	options = options.withDefaults(ccAPI)
+	if options.EventsURL == "" {
+		options.EventsURL = ccEventsAPI
+	}
+	return &ClimaCell{options: options}
+}
+
+// CheckKey tests the API key with a request for a single realtime field.
+// Like any other request, it counts toward the daily limit.
+func (f *ClimaCell) CheckKey(ctx context.Context) error {
+	query := url.Values{}
+	query.Set("lat", "0")
+	query.Set("lon", "0")
+	query.Set("apikey", f.options.APIKey)
+	query.Set("fields", "temp")
+
+	url := fmt.Sprintf("%s/realtime?%s", f.options.BaseURL, query.Encode())
+	_, err := f.options.Client.Get(ctx, url)
+	return classify(ClimaCellName, err, nil)
+}
+
+// Forecast returns the forecast for a given location
+func (f *ClimaCell) Forecast(ctx context.Context, l forecast.Location) (weather forecast.Weather, err error) {
+	var hourly []ccHourly
+	var daily []ccDaily
+	var current ccCurrent
+	var events []ccEvent
+
+	err = fetch.All(ctx,
+		func(ctx context.Context) (err error) {
+			hourly, err = f.HourlyForecast(ctx, l)
+			return
+		},
+		func(ctx context.Context) (err error) {
+			daily, err = f.DailyForecast(ctx, l)
+			return
+		},
+		func(ctx context.Context) (err error) {
+			current, err = f.CurrentConditions(ctx, l)
+			return
+		},
+		func(ctx context.Context) error {
+			// Events aren't available on every plan, so a failure here isn't
+			// fatal
+			events, _ = f.Events(ctx, l)
+			return nil
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	weather.URL = fmt.Sprintf("%s?lat=%f&lon=%f&units=%s", ccAPI, l.Latitude, l.Longitude, ccUnits)
+
+	for _, e := range events {
+		title := e.EventValues.Title
+		if title == "" {
+			title = e.EventValues.Headline
+		}
+
+		description := e.EventValues.Description
+		if e.EventValues.Instruction != "" {
+			description += "\n\n" + e.EventValues.Instruction
+		}
+
+		severity := forecast.ParseSeverity(e.Severity)
+		if severity == forecast.SeverityUnknown {
+			severity = forecast.ParseSeverity(title)
+		}
+
+		alert := forecast.Alert{
+			Title:       title,
+			Description: description,
+			Severity:    severity,
+			Onset:       parseTime(e.StartTime),
+			Expires:     parseTime(e.EndTime),
+			Sender:      e.EventValues.Origin,
+		}
+		if e.EventValues.AreaDesc != "" {
+			alert.Regions = strings.Split(e.EventValues.AreaDesc, "; ")
+		}
+		weather.Alerts = append(weather.Alerts, alert)
+	}
+
+	weather.Current.Time = parseTime(current.Time.Value)
+	weather.Current.Summary = ccDescription(current.WeatherCode.Value, f.options.Language)
+	weather.Current.Icon = ccIconNames[current.WeatherCode.Value]
+	weather.Current.Humidity = current.Humidity.Value
+	weather.Current.Temp = forecast.Temperature(current.Temp.Value)
+	weather.Current.ApparentTemp = forecast.Temperature(current.ApparentTemp.Value)
+	weather.Current.WindSpeed = forecast.Speed(current.WindSpeed.Value)
+	weather.Current.WindGust = forecast.Speed(current.WindGust.Value)
+	weather.Current.Pressure = forecast.Pressure(current.Pressure.Value)
+	weather.Current.Visibility = forecast.Distance(current.Visibility.Value)
+
+	for _, d := range daily {
+		lowTemp := ccMin(d.Temp)
+		highTemp := ccMax(d.Temp)
+		windSpeed := ccMax(d.WindSpeed)
+
+		f := forecast.Daily{
+			Date:         parseDate(d.Date.Value),
+			Icon:         ccIconNames[d.WeatherCode.Value],
+			Summary:      ccDescription(d.WeatherCode.Value, f.options.Language),
+			HighTemp:     forecast.Temperature(highTemp),
+			LowTemp:      forecast.Temperature(lowTemp),
+			Sunrise:      parseTime(d.SunriseTime.Value),
+			Sunset:       parseTime(d.SunsetTime.Value),
+			Precip:       d.PrecipProbability.Value,
+			PrecipAmount: forecast.Precipitation(d.PrecipAmount.Value),
+			WindSpeed:    forecast.Speed(windSpeed),
+		}
+		weather.Daily = append(weather.Daily, f)
+	}
+
+	for _, d := range hourly {
+		f := forecast.Hourly{
+			Time:         parseTime(d.Time.Value),
+			Icon:         ccIconNames[d.WeatherCode.Value],
+			Summary:      ccDescription(d.WeatherCode.Value, f.options.Language),
+			Temp:         forecast.Temperature(d.Temp.Value),
+			ApparentTemp: forecast.Temperature(d.ApparentTemp.Value),
+			Precip:       d.PrecipProbability.Value,
+			PrecipAmount: forecast.Precipitation(d.PrecipAmount.Value),
+			WindSpeed:    forecast.Speed(d.WindSpeed.Value),
+			WindGust:     forecast.Speed(d.WindGust.Value),
+		}
+		weather.Hourly = append(weather.Hourly, f)
+	}
+
+	return
+}
+
+// DailyForecast returns the daily forecast for a location
+func (f *ClimaCell) DailyForecast(ctx context.Context, l forecast.Location) (data []ccDaily, err error) {
+	query := url.Values{}
+	query.Set("lat", fmt.Sprintf("%f", l.Latitude))
+	query.Set("lon", fmt.Sprintf("%f", l.Longitude))
+	query.Set("apikey", f.options.APIKey)
+	query.Set("unit_system", ccUnits)
+	query.Set("start_time", "now")
+	query.Set("fields", "temp,feels_like,precipitation_probability,precipitation_accumulation,wind_speed,weather_code,sunrise,sunset")
+
+	url := fmt.Sprintf("%s/forecast/daily?%s", f.options.BaseURL, query.Encode())
+	_, err = f.options.Client.GetJSON(ctx, url, &data)
+	return data, classify(ClimaCellName, err, nil)
+}
+
+// HourlyForecast returns the hourly forecast for a location
+func (f *ClimaCell) HourlyForecast(ctx context.Context, l forecast.Location) (data []ccHourly, err error) {
+	query := url.Values{}
+	query.Set("lat", fmt.Sprintf("%f", l.Latitude))
+	query.Set("lon", fmt.Sprintf("%f", l.Longitude))
+	query.Set("apikey", f.options.APIKey)
+	query.Set("unit_system", ccUnits)
+	query.Set("fields", "temp,feels_like,precipitation_probability,precipitation,wind_speed,wind_gust,weather_code")
+
+	url := fmt.Sprintf("%s/forecast/hourly?%s", f.options.BaseURL, query.Encode())
+	_, err = f.options.Client.GetJSON(ctx, url, &data)
+	return data, classify(ClimaCellName, err, nil)
+}
+
+// CurrentConditions returns the current conditions at a location
+func (f *ClimaCell) CurrentConditions(ctx context.Context, l forecast.Location) (data ccCurrent, err error) {
+	query := url.Values{}
+	query.Set("lat", fmt.Sprintf("%f", l.Latitude))
+	query.Set("lon", fmt.Sprintf("%f", l.Longitude))
+	query.Set("apikey", f.options.APIKey)
+	query.Set("unit_system", ccUnits)
+	query.Set("fields", "temp,feels_like,weather_code,humidity,wind_speed,wind_gust,baro_pressure,visibility")
+
+	url := fmt.Sprintf("%s/realtime?%s", f.options.BaseURL, query.Encode())
+	_, err = f.options.Client.GetJSON(ctx, url, &data)
+	return data, classify(ClimaCellName, err, nil)
+}
+
+// Events returns active weather events (alerts) for a location
+func (f *ClimaCell) Events(ctx context.Context, l forecast.Location) (data []ccEvent, err error) {
+	query := url.Values{}
+	query.Set("location", fmt.Sprintf("%f,%f", l.Latitude, l.Longitude))
+	query.Set("apikey", f.options.APIKey)
+	query.Set("insights", ccInsights)
+	query.Set("buffer", "1")
+
+	url := fmt.Sprintf("%s?%s", f.options.EventsURL, query.Encode())
+
+	var events ccEvents
+	_, err = f.options.Client.GetJSON(ctx, url, &events)
+	return events.Data.Events, classify(ClimaCellName, err, nil)
+}
+
+// ccMin returns the first minimum in a list of ranges, or 0 if there isn't
+// one
+func ccMin(ranges []ccRange) float64 {
+	for _, r := range ranges {
+		if r.Min.Units != "" {
+			return r.Min.Value
+		}
+	}
+	return 0
+}
+
+// ccMax returns the first maximum in a list of ranges, or 0 if there isn't
+// one
+func ccMax(ranges []ccRange) float64 {
+	for _, r := range ranges {
+		if r.Max.Units != "" {
+			return r.Max.Value
+		}
+	}
+	return 0
+}
+
+// ccDescription returns the description of a weather code in a language
+func ccDescription(code, language string) string {
+	if d, ok := ccDescriptionTranslations[language][code]; ok {
+		return d
+	}
+	return ccDescriptions[code]
+}
+
+func parseTime(timeStr string) time.Time {
+	date, _ := time.Parse(time.RFC3339, timeStr)
+	return date.In(time.Local)
+}
+
+func parseDate(dateStr string) time.Time {
+	date, _ := time.Parse("2006-01-02", dateStr)
+	return date
+}
@@ -0,0 +1,209 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/fetch"
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+var dsIconNames = map[string]string{
+	"clear-day":           "clear",
+	"clear-night":         "nt_clear",
+	"partly-cloudy-day":   "partlycloudy",
+	"partly-cloudy-night": "nt_partlycloudy",
+	"wind":                "hazy",
+}
+
+const dsAPI = "https://api.darksky.net/forecast"
+
+// DarkSky is a weather service handle
+type DarkSky struct {
+	options Options
+}
+
+type dsConditions struct {
+	Temperature         float64 `json:"temperature"`
+	Icon                string  `json:"icon"`
+	Humidity            float64 `json:"humidity"`
+	Summary             string  `json:"summary"`
+	ApparentTemperature float64 `json:"apparentTemperature"`
+	PrecipProbability   float64 `json:"precipProbability"`
+	WindSpeed           float64 `json:"windSpeed"`
+	WindGust            float64 `json:"windGust"`
+	Pressure            float64 `json:"pressure"`
+	Visibility          float64 `json:"visibility"`
+	Time                int64   `json:"time"`
+}
+
+type dsWeather struct {
+	Daily struct {
+		Icon string `json:"icon"`
+		Data []struct {
+			PrecipType        string  `json:"precipType"`
+			TempMin           float64 `json:"temperatureMin"`
+			TempMax           float64 `json:"temperatureMax"`
+			Summary           string  `json:"summary"`
+			SunsetTime        int64   `json:"sunsetTime"`
+			SunriseTime       int64   `json:"sunriseTime"`
+			PrecipProbability float64 `json:"precipProbability"`
+			PrecipIntensity   float64 `json:"precipIntensity"`
+			WindSpeed         float64 `json:"windSpeed"`
+			WindGust          float64 `json:"windGust"`
+			Icon              string  `json:"icon"`
+			Time              int64   `json:"time"`
+		} `json:"data"`
+		Summary string `json:"summary"`
+	} `json:"daily"`
+	Hourly struct {
+		Icon    string `json:"icon"`
+		Summary string `json:"summary"`
+		Data    []struct {
+			ApparentTemp      float64 `json:"apparentTemperature"`
+			Humidity          float64 `json:"humidity"`
+			Icon              string  `json:"icon"`
+			PrecipProbability float64 `json:"precipProbability"`
+			PrecipIntensity   float64 `json:"precipIntensity"`
+			WindSpeed         float64 `json:"windSpeed"`
+			WindGust          float64 `json:"windGust"`
+			Summary           string  `json:"summary"`
+			Temp              float64 `json:"temperature"`
+			Time              int64   `json:"time"`
+		} `json:"data"`
+	} `json:"hourly"`
+	Currently dsConditions `json:"currently"`
+	Flags     struct {
+		Units string `json:"units"`
+	} `json:"flags"`
+	Alerts []struct {
+		Title       string   `json:"title"`
+		Regions     []string `json:"regions"`
+		Severity    string   `json:"severity"`
+		Time        int64    `json:"time"`
+		Expires     int64    `json:"expires"`
+		Description string   `json:"description"`
+		URI         string   `json:"uri"`
+	} `json:"alerts"`
+}
+
+// dsStatusError identifies Dark Sky's usage limit error, which is sent with a
+// 403 status like an invalid key
+func dsStatusError(e *fetch.StatusError) error {
+	if e.StatusCode == http.StatusForbidden && bodyContains(e, "limit") {
+		return &QuotaError{Service: DarkSkyName, Err: e}
+	}
+	return nil
+}
+
+// NewDarkSky returns a new DarkSky handle
+func NewDarkSky(options Options) *DarkSky {
+	return &DarkSky{options: options.withDefaults(dsAPI)}
+}
+
+// CheckKey tests the API key with a request for current conditions only.
+// Like any other request, it counts toward the daily limit.
+func (f *DarkSky) CheckKey(ctx context.Context) error {
+	query := url.Values{}
+	query.Set("exclude", "minutely,hourly,daily,alerts,flags")
+
+	url := fmt.Sprintf("%s/%s/0,0?%s", f.options.BaseURL, f.options.APIKey, query.Encode())
+	_, err := f.options.Client.Get(ctx, url)
+	return classify(DarkSkyName, err, dsStatusError)
+}
+
+// Forecast returns the forecast for a given location
+func (f *DarkSky) Forecast(ctx context.Context, l forecast.Location) (weather forecast.Weather, err error) {
+	query := url.Values{}
+	query.Set("exclude", "minutely")
+
+	// Always request SI units; values are converted for display
+	query.Set("units", "si")
+	query.Set("lang", f.options.Language)
+
+	url := fmt.Sprintf("%s/%s/%f,%f?%s", f.options.BaseURL, f.options.APIKey, l.Latitude, l.Longitude, query.Encode())
+
+	var w dsWeather
+	if _, err = f.options.Client.GetJSON(ctx, url, &w); err != nil {
+		return weather, classify(DarkSkyName, err, dsStatusError)
+	}
+
+	for _, a := range w.Alerts {
+		alert := forecast.Alert{
+			Title:       a.Title,
+			Description: a.Description,
+			Severity:    forecast.ParseSeverity(a.Severity),
+			Onset:       time.Unix(a.Time, 0),
+			Expires:     time.Unix(a.Expires, 0),
+			Regions:     a.Regions,
+			URL:         a.URI,
+		}
+		weather.Alerts = append(weather.Alerts, alert)
+	}
+
+	units := w.Flags.Units
+
+	weather.URL = fmt.Sprintf("https://darksky.net/forecast/%f,%f", l.Latitude, l.Longitude)
+
+	weather.Current.Time = time.Unix(w.Currently.Time, 0)
+	weather.Current.Summary = w.Currently.Summary
+	weather.Current.Icon = fromDSIconName(w.Currently.Icon)
+	weather.Current.Humidity = w.Currently.Humidity * 100
+	weather.Current.Temp = fromDSTemp(w.Currently.Temperature, units)
+	weather.Current.ApparentTemp = fromDSTemp(w.Currently.ApparentTemperature, units)
+	weather.Current.WindSpeed = forecast.Speed(w.Currently.WindSpeed)
+	weather.Current.WindGust = forecast.Speed(w.Currently.WindGust)
+	weather.Current.Pressure = forecast.Pressure(w.Currently.Pressure)
+	weather.Current.Visibility = forecast.Distance(w.Currently.Visibility)
+
+	for _, d := range w.Daily.Data {
+		f := forecast.Daily{
+			Date:         time.Unix(d.Time, 0),
+			Icon:         fromDSIconName(d.Icon),
+			Precip:       int(d.PrecipProbability * 100),
+			PrecipAmount: forecast.Precipitation(d.PrecipIntensity * 24),
+			Summary:      d.Summary,
+			HighTemp:     fromDSTemp(d.TempMax, units),
+			LowTemp:      fromDSTemp(d.TempMin, units),
+			Sunrise:      time.Unix(d.SunriseTime, 0),
+			Sunset:       time.Unix(d.SunsetTime, 0),
+			WindSpeed:    forecast.Speed(d.WindSpeed),
+			WindGust:     forecast.Speed(d.WindGust),
+		}
+		weather.Daily = append(weather.Daily, f)
+	}
+
+	for _, d := range w.Hourly.Data {
+		f := forecast.Hourly{
+			Time:         time.Unix(d.Time, 0),
+			Icon:         fromDSIconName(d.Icon),
+			Precip:       int(d.PrecipProbability * 100),
+			PrecipAmount: forecast.Precipitation(d.PrecipIntensity),
+			Summary:      d.Summary,
+			Temp:         fromDSTemp(d.Temp, units),
+			ApparentTemp: fromDSTemp(d.ApparentTemp, units),
+			WindSpeed:    forecast.Speed(d.WindSpeed),
+			WindGust:     forecast.Speed(d.WindGust),
+		}
+		weather.Hourly = append(weather.Hourly, f)
+	}
+
+	return
+}
+
+func fromDSIconName(name string) string {
+	if n, ok := dsIconNames[name]; ok {
+		return n
+	}
+	return name
+}
+
+func fromDSTemp(temp float64, units string) forecast.Temperature {
+	if units == "si" {
+		return forecast.Temperature(temp)
+	}
+	return forecast.Temperature((temp - 32.0) * (5.0 / 9.0))
+}
@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/fetch"
+)
+
+// InvalidKeyError indicates that a service rejected its API key
+type InvalidKeyError struct {
+	Service string
+	Err     error
+}
+
+func (e *InvalidKeyError) Error() string {
+	return fmt.Sprintf("%s rejected the API key (%v)", e.Service, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *InvalidKeyError) Unwrap() error { return e.Err }
+
+// QuotaError indicates that a service refused a request because a request
+// limit was reached
+type QuotaError struct {
+	Service string
+	Err     error
+
+	// RetryAfter is how long the service asked to wait, or zero if it didn't
+	// say
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s request limit reached (%v)", e.Service, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *QuotaError) Unwrap() error { return e.Err }
+
+// NotFoundError indicates that a service has no forecast for a location
+type NotFoundError struct {
+	Service string
+	Err     error
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s has no forecast for this location (%v)", e.Service, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *NotFoundError) Unwrap() error { return e.Err }
+
+// UnavailableError indicates that a service couldn't be reached or failed
+// to handle a request
+type UnavailableError struct {
+	Service string
+	Err     error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s is unavailable (%v)", e.Service, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *UnavailableError) Unwrap() error { return e.Err }
+
+// DecodeError indicates that a service's response couldn't be understood
+type DecodeError struct {
+	Service string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s sent an unreadable response (%v)", e.Service, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// classify converts a request error to one of the error types above. The
+// meaning of statuses that services use differently can be decided by
+// override, which returns nil to use the default.
+func classify(service string, err error, override func(*fetch.StatusError) error) error {
+	if err == nil {
+		return nil
+	}
+
+	var statusErr *fetch.StatusError
+	if errors.As(err, &statusErr) {
+		if override != nil {
+			if e := override(statusErr); e != nil {
+				return e
+			}
+		}
+
+		switch code := statusErr.StatusCode; {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return &InvalidKeyError{Service: service, Err: err}
+		case code == http.StatusTooManyRequests:
+			return &QuotaError{Service: service, Err: err, RetryAfter: statusErr.RetryAfter(time.Now())}
+		case code == http.StatusNotFound || code == http.StatusBadRequest:
+			return &NotFoundError{Service: service, Err: err}
+		case code >= 500:
+			return &UnavailableError{Service: service, Err: err}
+		}
+		return err
+	}
+
+	var netErr *fetch.NetworkError
+	if errors.As(err, &netErr) {
+		return &UnavailableError{Service: service, Err: err}
+	}
+
+	var decodeErr *fetch.DecodeError
+	if errors.As(err, &decodeErr) {
+		return &DecodeError{Service: service, Err: err}
+	}
+
+	return err
+}
+
+// bodyContains indicates whether an error response's body contains some
+// text, ignoring case
+func bodyContains(e *fetch.StatusError, text string) bool {
+	return strings.Contains(strings.ToLower(string(e.Body)), text)
+}
@@ -0,0 +1,223 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+var owIconNames = map[string]string{
+	"01d": "clear",
+	"01n": "nt_clear",
+	"02d": "partlycloudy",
+	"02n": "nt_partlycloudy",
+	"03d": "cloudy",
+	"03n": "nt_cloudy",
+	"04d": "mostlycloudy",
+	"04n": "nt_mostlycloudy",
+	"09d": "rain",
+	"09n": "nt_rain",
+	"10d": "rain",
+	"10n": "nt_rain",
+	"11d": "tstorms",
+	"11n": "nt_tstorms",
+	"13d": "snow",
+	"13n": "nt_snow",
+	"50d": "hazy",
+	"50n": "nt_hazy",
+}
+
+const owAPI = "https://api.openweathermap.org/data/2.5/onecall"
+
+// OpenWeather is a weather service handle
+type OpenWeather struct {
+	options Options
+}
+
+// owConditions are the weather conditions reported for a period, most
+// significant first
+type owConditions []struct {
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// summary returns the description and icon of the main condition, which are
+// empty if no conditions were reported
+func (c owConditions) summary() (description, icon string) {
+	if len(c) == 0 {
+		return
+	}
+	return c[0].Description, fromOWIconName(c[0].Icon)
+}
+
+type owWeather struct {
+	Current struct {
+		Temperature         float64      `json:"temp"`
+		Humidity            float64      `json:"humidity"`
+		ApparentTemperature float64      `json:"feels_like"`
+		Pressure            float64      `json:"pressure"`
+		Visibility          float64      `json:"visibility"`
+		WindSpeed           float64      `json:"wind_speed"`
+		WindGust            float64      `json:"wind_gust"`
+		Time                int64        `json:"dt"`
+		Weather             owConditions `json:"weather"`
+	} `json:"current"`
+	Daily []struct {
+		Time         int64   `json:"dt"`
+		Humidity     float64 `json:"humidity"`
+		Pop          float64 `json:"pop"`
+		ApparentTemp struct {
+			Day     float64 `json:"day"`
+			Evening float64 `json:"eve"`
+			Morning float64 `json:"morn"`
+			Night   float64 `json:"night"`
+		} `json:"feels_like"`
+		Temp struct {
+			Min float64 `json:"min"`
+			Max float64 `json:"max"`
+		} `json:"temp"`
+		SunsetTime  int64        `json:"sunset"`
+		SunriseTime int64        `json:"sunrise"`
+		WindSpeed   float64      `json:"wind_speed"`
+		WindGust    float64      `json:"wind_gust"`
+		Rain        float64      `json:"rain"`
+		Snow        float64      `json:"snow"`
+		Weather     owConditions `json:"weather"`
+	} `json:"daily"`
+	Hourly []struct {
+		Time         int64   `json:"dt"`
+		ApparentTemp float64 `json:"feels_like"`
+		Humidity     float64 `json:"humidity"`
+		Pop          float64 `json:"pop"`
+		Temp         float64 `json:"temp"`
+		WindSpeed    float64 `json:"wind_speed"`
+		WindGust     float64 `json:"wind_gust"`
+		Rain         struct {
+			OneHour float64 `json:"1h"`
+		} `json:"rain"`
+		Snow struct {
+			OneHour float64 `json:"1h"`
+		} `json:"snow"`
+		Weather owConditions `json:"weather"`
+	} `json:"hourly"`
+	Alerts []struct {
+		SenderName  string   `json:"sender_name"`
+		Event       string   `json:"event"`
+		Start       int64    `json:"start"`
+		End         int64    `json:"end"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+	} `json:"alerts"`
+	Timezone string `json:"timezone"`
+}
+
+// NewOpenWeather returns a new OpenWeather handle
+func NewOpenWeather(options Options) *OpenWeather {
+	return &OpenWeather{options: options.withDefaults(owAPI)}
+}
+
+// CheckKey tests the API key with a request that excludes all of the
+// forecast data. Like any other request, it counts toward the daily limit.
+func (f *OpenWeather) CheckKey(ctx context.Context) error {
+	query := url.Values{}
+	query.Set("lat", "0")
+	query.Set("lon", "0")
+	query.Set("appid", f.options.APIKey)
+	query.Set("exclude", "current,minutely,hourly,daily,alerts")
+
+	url := fmt.Sprintf("%s?%s", f.options.BaseURL, query.Encode())
+	_, err := f.options.Client.Get(ctx, url)
+	return classify(OpenWeatherName, err, nil)
+}
+
+// Forecast returns the forecast for a given location
+func (f *OpenWeather) Forecast(ctx context.Context, l forecast.Location) (weather forecast.Weather, err error) {
+	units := "metric"
+
+	query := url.Values{}
+	query.Set("lat", fmt.Sprintf("%f", l.Latitude))
+	query.Set("lon", fmt.Sprintf("%f", l.Longitude))
+	query.Set("appid", f.options.APIKey)
+	query.Set("units", units)
+	query.Set("lang", f.options.Language)
+
+	url := fmt.Sprintf("%s?%s", f.options.BaseURL, query.Encode())
+
+	var w owWeather
+	if _, err = f.options.Client.GetJSON(ctx, url, &w); err != nil {
+		return weather, classify(OpenWeatherName, err, nil)
+	}
+
+	weather.URL = fmt.Sprintf("%s?lat=%f&lon=%f&units=%s", owAPI, l.Latitude, l.Longitude, units)
+
+	for _, a := range w.Alerts {
+		alert := forecast.Alert{
+			Title:       a.Event,
+			Description: a.Description,
+			Severity:    forecast.ParseSeverity(a.Event),
+			Onset:       time.Unix(a.Start, 0),
+			Expires:     time.Unix(a.End, 0),
+			Sender:      a.SenderName,
+		}
+		weather.Alerts = append(weather.Alerts, alert)
+	}
+
+	weather.Current.Time = time.Unix(w.Current.Time, 0)
+	weather.Current.Summary, weather.Current.Icon = w.Current.Weather.summary()
+	weather.Current.Humidity = w.Current.Humidity
+	weather.Current.Temp = forecast.Temperature(w.Current.Temperature)
+	weather.Current.ApparentTemp = forecast.Temperature(w.Current.ApparentTemperature)
+	weather.Current.WindSpeed = forecast.Speed(w.Current.WindSpeed)
+	weather.Current.WindGust = forecast.Speed(w.Current.WindGust)
+	weather.Current.Pressure = forecast.Pressure(w.Current.Pressure)
+	weather.Current.Visibility = forecast.Distance(w.Current.Visibility / 1000)
+
+	for _, d := range w.Daily {
+		f := forecast.Daily{
+			Date:         time.Unix(d.Time, 0),
+			HighTemp:     forecast.Temperature(d.Temp.Max),
+			LowTemp:      forecast.Temperature(d.Temp.Min),
+			Sunrise:      time.Unix(d.SunriseTime, 0),
+			Sunset:       time.Unix(d.SunsetTime, 0),
+			PrecipAmount: forecast.Precipitation(d.Rain + d.Snow),
+			WindSpeed:    forecast.Speed(d.WindSpeed),
+			WindGust:     forecast.Speed(d.WindGust),
+			Precip:       owPrecipChance(d.Pop),
+		}
+		f.Summary, f.Icon = d.Weather.summary()
+		weather.Daily = append(weather.Daily, f)
+	}
+
+	for _, d := range w.Hourly {
+		f := forecast.Hourly{
+			Time:         time.Unix(d.Time, 0),
+			Temp:         forecast.Temperature(d.Temp),
+			ApparentTemp: forecast.Temperature(d.ApparentTemp),
+			Precip:       owPrecipChance(d.Pop),
+			PrecipAmount: forecast.Precipitation(d.Rain.OneHour + d.Snow.OneHour),
+			WindSpeed:    forecast.Speed(d.WindSpeed),
+			WindGust:     forecast.Speed(d.WindGust),
+		}
+		f.Summary, f.Icon = d.Weather.summary()
+		weather.Hourly = append(weather.Hourly, f)
+	}
+
+	return
+}
+
+// owPrecipChance converts a probability of precipitation from 0 to 1 to a
+// percentage
+func owPrecipChance(pop float64) int {
+	return int(math.Round(pop * 100))
+}
+
+func fromOWIconName(name string) string {
+	if n, ok := owIconNames[name]; ok {
+		return n
+	}
+	return name
+}
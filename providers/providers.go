@@ -0,0 +1,56 @@
+// Package providers fetches forecasts from weather services and converts
+// them to the forecast model.
+package providers
+
+import (
+	"context"
+
+	"github.com/jason0x43/alfred-weather/fetch"
+)
+
+// Service names
+const (
+	DarkSkyName     = "Dark Sky"
+	OpenWeatherName = "OpenWeather"
+	ClimaCellName   = "ClimaCell"
+)
+
+// KeyChecker is a provider that can test its API key
+type KeyChecker interface {
+	CheckKey(context.Context) error
+}
+
+// Options configure a provider handle
+type Options struct {
+	// APIKey is the key used to authenticate with the service
+	APIKey string
+
+	// Language is the language code used for text such as summaries. The
+	// default is "en".
+	Language string
+
+	// Client makes the service's HTTP requests. The default is a fetch.Client
+	// with default settings.
+	Client *fetch.Client
+
+	// BaseURL replaces the service's API endpoint, such as to use a local
+	// stand-in server
+	BaseURL string
+
+	// EventsURL replaces the ClimaCell events endpoint
+	EventsURL string
+}
+
+// withDefaults fills in unset options, using a default API endpoint
+func (o Options) withDefaults(api string) Options {
+	if o.Language == "" {
+		o.Language = "en"
+	}
+	if o.Client == nil {
+		o.Client = fetch.New(nil)
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = api
+	}
+	return o
+}
@@ -0,0 +1,223 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/fetch"
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var berlin = forecast.Location{Name: "Berlin", Latitude: 52.52, Longitude: 13.405}
+
+func TestMain(m *testing.M) {
+	// Times are converted to the local time zone, so use a fixed one to keep
+	// the golden files stable
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+// newStubService starts a stand-in for a weather service that answers
+// requests with recorded responses from testdata, keyed by path. Paths that
+// aren't in routes get a 404.
+func newStubService(t *testing.T, routes map[string]string) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := ioutil.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Errorf("Unable to read fixture: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newErrorService starts a stand-in service that answers every request with
+// a status and body
+func newErrorService(t *testing.T, status int, body string) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// testClient returns a client that doesn't retry, so that error responses
+// are returned right away
+func testClient() *fetch.Client {
+	c := fetch.New(nil)
+	c.Retries = 0
+	return c
+}
+
+// providerTests describe each provider and its recorded responses
+var providerTests = []struct {
+	name   string
+	routes map[string]string
+	new    func(baseURL string) forecast.Provider
+}{
+	{
+		name:   "darksky",
+		routes: map[string]string{"/key/52.520000,13.405000": "darksky.json"},
+		new: func(baseURL string) forecast.Provider {
+			return NewDarkSky(Options{APIKey: "key", Client: testClient(), BaseURL: baseURL})
+		},
+	},
+	{
+		name:   "openweather",
+		routes: map[string]string{"/": "openweather.json"},
+		new: func(baseURL string) forecast.Provider {
+			return NewOpenWeather(Options{APIKey: "key", Client: testClient(), BaseURL: baseURL + "/"})
+		},
+	},
+	{
+		name: "climacell",
+		routes: map[string]string{
+			"/realtime":        "climacell_realtime.json",
+			"/forecast/hourly": "climacell_hourly.json",
+			"/forecast/daily":  "climacell_daily.json",
+			"/events":          "climacell_events.json",
+		},
+		new: func(baseURL string) forecast.Provider {
+			return NewClimaCell(Options{
+				APIKey:    "key",
+				Client:    testClient(),
+				BaseURL:   baseURL,
+				EventsURL: baseURL + "/events",
+			})
+		},
+	},
+}
+
+func TestForecast(t *testing.T) {
+	for _, test := range providerTests {
+		t.Run(test.name, func(t *testing.T) {
+			service := newStubService(t, test.routes)
+
+			weather, err := test.new(service.URL).Forecast(context.Background(), berlin)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.MarshalIndent(weather, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, test.name+".golden", append(got, '\n'))
+		})
+	}
+}
+
+func TestForecastErrors(t *testing.T) {
+	errorTests := []struct {
+		name   string
+		status int
+		body   string
+		check  func(error) bool
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"message":"Invalid API key"}`, func(err error) bool {
+			var e *InvalidKeyError
+			return errors.As(err, &e)
+		}},
+		{"too many requests", http.StatusTooManyRequests, `{"message":"Too many requests"}`, func(err error) bool {
+			var e *QuotaError
+			return errors.As(err, &e)
+		}},
+		{"truncated body", http.StatusOK, `{"current": {"temp": 21.`, func(err error) bool {
+			var e *DecodeError
+			return errors.As(err, &e)
+		}},
+	}
+
+	for _, test := range providerTests {
+		for _, errorTest := range errorTests {
+			t.Run(test.name+"/"+errorTest.name, func(t *testing.T) {
+				service := newErrorService(t, errorTest.status, errorTest.body)
+
+				_, err := test.new(service.URL).Forecast(context.Background(), berlin)
+				if !errorTest.check(err) {
+					t.Errorf("error = %v (%T)", err, err)
+				}
+			})
+		}
+	}
+}
+
+func TestOpenWeatherEmptyConditions(t *testing.T) {
+	service := newStubService(t, map[string]string{"/": "openweather_empty_conditions.json"})
+	provider := NewOpenWeather(Options{APIKey: "key", Client: testClient(), BaseURL: service.URL + "/"})
+
+	weather, err := provider.Forecast(context.Background(), berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weather.Current.Summary != "" || weather.Current.Icon != "" {
+		t.Errorf("current conditions = %q, %q; want none", weather.Current.Summary, weather.Current.Icon)
+	}
+	if len(weather.Daily) != 1 || len(weather.Hourly) != 1 {
+		t.Fatalf("got %d days and %d hours, want 1 of each", len(weather.Daily), len(weather.Hourly))
+	}
+	if weather.Daily[0].Icon != "" || weather.Hourly[0].Icon != "" {
+		t.Error("periods without conditions have icons")
+	}
+}
+
+func TestCheckKey(t *testing.T) {
+	for _, test := range providerTests {
+		t.Run(test.name, func(t *testing.T) {
+			service := newErrorService(t, http.StatusUnauthorized, `{"message":"Invalid API key"}`)
+
+			checker, ok := test.new(service.URL).(KeyChecker)
+			if !ok {
+				t.Fatal("provider can't check keys")
+			}
+			var e *InvalidKeyError
+			if err := checker.CheckKey(context.Background()); !errors.As(err, &e) {
+				t.Errorf("error = %v, want an InvalidKeyError", err)
+			}
+		})
+	}
+}
+
+// checkGolden compares output with a golden file in testdata, or rewrites the
+// file when the tests are run with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output doesn't match %s (run the tests with -update to accept it)\ngot:\n%s", path, got)
+	}
+}
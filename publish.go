@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// mqttState is the state payload published for each location
+type mqttState struct {
+	Time          time.Time  `json:"time"`
+	Summary       string     `json:"summary"`
+	TempC         float64    `json:"temp_c"`
+	ApparentTempC float64    `json:"apparent_temp_c"`
+	HumidityPct   float64    `json:"humidity_percent"`
+	WindSpeedMS   float64    `json:"wind_speed_ms"`
+	PressureHPa   float64    `json:"pressure_hpa"`
+	Alerts        int        `json:"alerts"`
+	Today         *jsonDaily `json:"today"`
+}
+
+// haSensor describes a Home Assistant sensor for one field of the state
+type haSensor struct {
+	Key         string
+	Name        string
+	Unit        string
+	DeviceClass string
+	Template    string
+}
+
+// haSensors are the sensors announced for each location
+var haSensors = []haSensor{
+	{Key: "temperature", Name: "Temperature", Unit: "°C", DeviceClass: "temperature", Template: "{{ value_json.temp_c }}"},
+	{Key: "apparent_temperature", Name: "Apparent temperature", Unit: "°C", DeviceClass: "temperature", Template: "{{ value_json.apparent_temp_c }}"},
+	{Key: "humidity", Name: "Humidity", Unit: "%", DeviceClass: "humidity", Template: "{{ value_json.humidity_percent }}"},
+	{Key: "wind_speed", Name: "Wind speed", Unit: "m/s", DeviceClass: "wind_speed", Template: "{{ value_json.wind_speed_ms }}"},
+	{Key: "pressure", Name: "Pressure", Unit: "hPa", DeviceClass: "atmospheric_pressure", Template: "{{ value_json.pressure_hpa }}"},
+	{Key: "conditions", Name: "Conditions", Template: "{{ value_json.summary }}"},
+	{Key: "alerts", Name: "Active alerts", Template: "{{ value_json.alerts }}"},
+	{Key: "today_high", Name: "Today's high", Unit: "°C", DeviceClass: "temperature", Template: "{{ value_json.today.high_temp_c if value_json.today else none }}"},
+	{Key: "today_low", Name: "Today's low", Unit: "°C", DeviceClass: "temperature", Template: "{{ value_json.today.low_temp_c if value_json.today else none }}"},
+	{Key: "today_precip", Name: "Today's precipitation chance", Unit: "%", Template: "{{ value_json.today.precip_chance if value_json.today else none }}"},
+	{Key: "today_summary", Name: "Today's forecast", Template: "{{ value_json.today.summary if value_json.today else none }}"},
+}
+
+// haDiscovery is a Home Assistant MQTT discovery payload
+type haDiscovery struct {
+	Name          string   `json:"name"`
+	UniqueID      string   `json:"unique_id"`
+	StateTopic    string   `json:"state_topic"`
+	ValueTemplate string   `json:"value_template"`
+	Unit          string   `json:"unit_of_measurement,omitempty"`
+	DeviceClass   string   `json:"device_class,omitempty"`
+	StateClass    string   `json:"state_class,omitempty"`
+	Device        haDevice `json:"device"`
+}
+
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// locationSlug returns a topic-safe identifier for a location
+func locationSlug(loc forecast.Location) string {
+	return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(loc.Name), "_"), "_")
+}
+
+// newMQTTState builds the state payload for a location's weather
+func newMQTTState(loc forecast.Location, w forecast.Weather, now time.Time) mqttState {
+	doc := newJSONForecast(loc, w, now)
+	state := mqttState{
+		Time:          doc.Current.Time,
+		Summary:       doc.Current.Summary,
+		TempC:         doc.Current.TempC,
+		ApparentTempC: doc.Current.ApparentTempC,
+		HumidityPct:   doc.Current.HumidityPct,
+		WindSpeedMS:   doc.Current.WindSpeedMS,
+		PressureHPa:   doc.Current.PressureHPa,
+		Alerts:        len(doc.Alerts),
+	}
+
+	today := now.Format("2006-01-02")
+	for i := range doc.Daily {
+		if doc.Daily[i].Date == today {
+			state.Today = &doc.Daily[i]
+			break
+		}
+	}
+
+	return state
+}
+
+// mqttPublisher publishes forecasts to an MQTT broker
+type mqttPublisher struct {
+	options         mqttOptions
+	topicPrefix     string
+	discoveryPrefix string
+}
+
+// stateTopic returns the state topic for a location
+func (p mqttPublisher) stateTopic(loc forecast.Location) string {
+	return fmt.Sprintf("%s/%s/state", p.topicPrefix, locationSlug(loc))
+}
+
+// publish sends discovery and state messages for the default and saved
+// locations. All messages are retained so that new subscribers, including
+// Home Assistant after a restart, see the latest values.
+func (p mqttPublisher) publish() (err error) {
+	var client *mqttClient
+	if client, err = dialMQTT(p.options); err != nil {
+		return
+	}
+	defer client.Close()
+
+	now := clock.Now()
+
+	for _, loc := range allLocations() {
+		weather, err := getForecast(loc, now)
+		if err != nil {
+			dlog.Printf("Unable to get forecast for %s: %v", loc.Name, err)
+			continue
+		}
+
+		slug := locationSlug(loc)
+
+		if p.discoveryPrefix != "" {
+			device := haDevice{
+				Identifiers:  []string{"alfred_weather_" + slug},
+				Name:         "Weather " + loc.ShortName,
+				Manufacturer: "alfred-weather",
+				Model:        config.Service,
+			}
+			for _, s := range haSensors {
+				d := haDiscovery{
+					Name:          s.Name,
+					UniqueID:      fmt.Sprintf("alfred_weather_%s_%s", slug, s.Key),
+					StateTopic:    p.stateTopic(loc),
+					ValueTemplate: s.Template,
+					Unit:          s.Unit,
+					DeviceClass:   s.DeviceClass,
+					Device:        device,
+				}
+				if s.Unit != "" {
+					d.StateClass = "measurement"
+				}
+				topic := fmt.Sprintf("%s/sensor/alfred_weather_%s/%s/config", p.discoveryPrefix, slug, s.Key)
+				data, _ := json.Marshal(d)
+				if err := client.Publish(topic, data, true); err != nil {
+					return err
+				}
+			}
+		}
+
+		data, _ := json.Marshal(newMQTTState(loc, weather, now))
+		if err := client.Publish(p.stateTopic(loc), data, true); err != nil {
+			return err
+		}
+		dlog.Printf("Published weather for %s", loc.Name)
+	}
+
+	return
+}
+
+// runPublish periodically publishes forecasts to an MQTT broker. It returns
+// an exit status.
+func runPublish(args []string) int {
+	defaultData, defaultCache := standaloneDirs()
+	hostname, _ := os.Hostname()
+
+	flags := flag.NewFlagSet("mqtt", flag.ContinueOnError)
+	broker := flags.String("broker", "tcp://localhost:1883", "broker URL (tcp:// or ssl://)")
+	clientID := flags.String("client-id", "alfred-weather-"+hostname, "MQTT client ID")
+	username := flags.String("username", "", "MQTT user name")
+	password := flags.String("password", os.Getenv("MQTT_PASSWORD"), "MQTT password (default: $MQTT_PASSWORD)")
+	prefix := flags.String("prefix", "alfred-weather", "prefix for state topics")
+	discovery := flags.String("discovery-prefix", "homeassistant", `Home Assistant discovery prefix ("" to disable discovery)`)
+	interval := flags.Duration("interval", 10*time.Minute, "time between updates")
+	once := flags.Bool("once", false, "publish once and exit")
+	dataDir := flags.String("data", defaultData, "directory holding config.json")
+	cacheDir := flags.String("cache", defaultCache, "directory holding cache.json")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := initStandaloneConfig(*dataDir, *cacheDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	if err := validateConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	publisher := mqttPublisher{
+		options: mqttOptions{
+			Broker:   *broker,
+			ClientID: *clientID,
+			Username: *username,
+			Password: *password,
+		},
+		topicPrefix:     strings.TrimSuffix(*prefix, "/"),
+		discoveryPrefix: strings.TrimSuffix(*discovery, "/"),
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		if err := publisher.publish(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			if *once {
+				return 1
+			}
+		}
+
+		if *once {
+			return 0
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			dlog.Printf("stopping")
+			return 0
+		}
+	}
+}
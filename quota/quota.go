@@ -0,0 +1,202 @@
+// Package quota counts the requests made to each weather service, so that
+// daily API limits can be tracked.
+package quota
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/sharedfile"
+)
+
+// Usage is the number of requests made with an API key in one day. Days are
+// counted in UTC, which is when most services reset their limits.
+type Usage struct {
+	Day      string `json:"day"`
+	Requests int    `json:"requests"`
+
+	// Limit and Remaining are the daily limit and remaining requests
+	// reported by the service, or -1 if the service hasn't reported them
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+}
+
+// Left returns the number of requests left in a daily budget, using the
+// service's own count of remaining requests if it's lower. A budget of 0
+// means no budget, in which case only the service's count is used, or -1 is
+// returned if the service hasn't reported one.
+func (u Usage) Left(budget int) int {
+	left := -1
+	if budget > 0 {
+		left = budget - u.Requests
+		if left < 0 {
+			left = 0
+		}
+	}
+	if u.Remaining >= 0 && (left < 0 || u.Remaining < left) {
+		left = u.Remaining
+	}
+	return left
+}
+
+// Tracker records request counts in a file. It's safe for concurrent use,
+// and several processes can share a file.
+type Tracker struct {
+	path  string
+	mu    sync.Mutex
+	usage map[string]Usage
+
+	// added counts the requests recorded since the tracker was last saved
+	added map[string]int
+}
+
+// Open loads a tracker from a file. A missing file gives an empty tracker.
+// If the file can't be read, an empty tracker is returned along with the
+// error.
+func Open(path string) (t *Tracker, err error) {
+	t = &Tracker{path: path, usage: map[string]Usage{}, added: map[string]int{}}
+
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	if err = json.Unmarshal(data, &t.usage); err != nil || t.usage == nil {
+		t.usage = map[string]Usage{}
+	}
+	return
+}
+
+// Record counts a request to a service, updating the usage with any rate
+// limit headers in the response
+func (t *Tracker) Record(service, apiKey string, header http.Header, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := Key(service, apiKey)
+	u := t.current(k, now)
+	if u.Day != t.usage[k].Day {
+		delete(t.added, k)
+	}
+	u.Requests++
+	t.added[k]++
+
+	if header != nil {
+		limit, remaining, calls := parseHeaders(header)
+		if limit >= 0 {
+			u.Limit = limit
+		}
+		if remaining >= 0 {
+			u.Remaining = remaining
+		}
+		// Some services report how many calls have been made, which also
+		// includes calls made elsewhere with the same key
+		if calls > u.Requests {
+			u.Requests = calls
+		}
+	}
+
+	t.usage[k] = u
+}
+
+// Usage returns the usage of an API key on the day of a given time
+func (t *Tracker) Usage(service, apiKey string, now time.Time) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current(Key(service, apiKey), now)
+}
+
+// Save writes the tracker to its file. Requests recorded by other processes
+// since the file was read are added to the counts first, so that the file
+// holds every process's requests.
+func (t *Tracker) Save() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return sharedfile.Update(t.path, func(data []byte) ([]byte, error) {
+		saved := map[string]Usage{}
+		if data != nil {
+			if err := json.Unmarshal(data, &saved); err != nil || saved == nil {
+				saved = map[string]Usage{}
+			}
+		}
+
+		for k, u := range t.usage {
+			saved[k] = merge(saved[k], u, t.added[k])
+		}
+		t.usage = saved
+		t.added = map[string]int{}
+
+		return json.MarshalIndent(t.usage, "", "\t")
+	})
+}
+
+// merge combines the saved usage of a key with the tracker's, which includes
+// a number of requests that haven't been saved yet
+func merge(saved, u Usage, added int) Usage {
+	if saved.Day > u.Day {
+		return saved
+	}
+	if saved.Day < u.Day {
+		return u
+	}
+
+	merged := saved
+	merged.Requests += added
+	// A count reported by the service may be higher than either
+	if u.Requests > merged.Requests {
+		merged.Requests = u.Requests
+	}
+	if u.Limit >= 0 {
+		merged.Limit = u.Limit
+	}
+	if u.Remaining >= 0 && (merged.Remaining < 0 || u.Remaining < merged.Remaining) {
+		merged.Remaining = u.Remaining
+	}
+	return merged
+}
+
+// current returns the usage for a key, starting a new count on a new day
+func (t *Tracker) current(k string, now time.Time) Usage {
+	day := now.UTC().Format("2006-01-02")
+	if u, ok := t.usage[k]; ok && u.Day == day {
+		return u
+	}
+	return Usage{Day: day, Limit: -1, Remaining: -1}
+}
+
+// Key identifies a service and API key in files such as the usage file. Keys
+// are hashed so that they aren't stored there.
+func Key(service, apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return service + ":" + hex.EncodeToString(sum[:6])
+}
+
+// parseHeaders reads rate limit headers. Values that aren't present are -1.
+func parseHeaders(header http.Header) (limit, remaining, calls int) {
+	limit = firstInt(header, "X-RateLimit-Limit-Day", "X-RateLimit-Limit", "RateLimit-Limit")
+	remaining = firstInt(header, "X-RateLimit-Remaining-Day", "X-RateLimit-Remaining", "RateLimit-Remaining")
+	calls = firstInt(header, "X-Forecast-API-Calls")
+	return
+}
+
+// firstInt returns the value of the first of a list of headers that holds an
+// integer, or -1
+func firstInt(header http.Header, names ...string) int {
+	for _, name := range names {
+		if v, err := strconv.Atoi(header.Get(name)); err == nil && v >= 0 {
+			return v
+		}
+	}
+	return -1
+}
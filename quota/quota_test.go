@@ -0,0 +1,113 @@
+package quota
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+func TestSaveMergesOtherProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+
+	// A long-running process opens the file, then other processes record
+	// requests while it runs
+	watcher, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		other, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other.Record("darksky", "key", nil, now)
+		if err := other.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	watcher.Record("darksky", "key", nil, now)
+	watcher.Record("darksky", "key", nil, now)
+	if err := watcher.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := watcher.Usage("darksky", "key", now).Requests; got != 5 {
+		t.Errorf("requests after save = %d, want 5", got)
+	}
+
+	// Saving again doesn't count the same requests twice
+	if err := watcher.Save(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := saved.Usage("darksky", "key", now).Requests; got != 5 {
+		t.Errorf("saved requests = %d, want 5", got)
+	}
+}
+
+func TestSaveKeepsNewerDay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+
+	stale, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.Record("darksky", "key", nil, now.AddDate(0, 0, -1))
+
+	fresh, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh.Record("darksky", "key", nil, now)
+	if err := fresh.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stale.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := stale.Usage("darksky", "key", now).Requests; got != 1 {
+		t.Errorf("requests = %d, want today's 1", got)
+	}
+}
+
+func TestRecordHeaders(t *testing.T) {
+	tracker, err := Open(filepath.Join(t.TempDir(), "usage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit-Day", "500")
+	header.Set("X-RateLimit-Remaining-Day", "120")
+	header.Set("X-Forecast-API-Calls", "380")
+	tracker.Record("darksky", "key", header, now)
+
+	u := tracker.Usage("darksky", "key", now)
+	if u.Requests != 380 || u.Limit != 500 || u.Remaining != 120 {
+		t.Errorf("usage = %+v, want the reported counts", u)
+	}
+	if left := u.Left(1000); left != 120 {
+		t.Errorf("left = %d, want the service's 120", left)
+	}
+}
+
+func TestKey(t *testing.T) {
+	k := Key("darksky", "0123456789abcdef0123456789abcdef")
+	if k != Key("darksky", "0123456789abcdef0123456789abcdef") {
+		t.Error("the same key gave different IDs")
+	}
+	if k == Key("openweather", "0123456789abcdef0123456789abcdef") || k == Key("darksky", "other") {
+		t.Error("different keys gave the same ID")
+	}
+	if strings.Contains(k, "0123456789abcdef") {
+		t.Errorf("ID %q contains the key", k)
+	}
+}
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"time"
-
 	"github.com/jason0x43/go-alfred"
 )
 
@@ -25,11 +23,11 @@ func (c RefreshCommand) About() alfred.CommandDef {
 func (c RefreshCommand) Items(arg, data string) (items []alfred.Item, err error) {
 	dlog.Printf("Running RefreshCommand")
 
-	cache.Time = time.Time{}
-	if err = alfred.SaveJSON(cacheFile, &cache); err == nil {
+	weatherCache.Clear()
+	if err = weatherCache.Save(); err == nil {
 		items = append(items, alfred.Item{
-			Title:    "Refreshed!",
-			Subtitle: "Data will be reloaded on the next forecast",
+			Title:    tr("Refreshed!"),
+			Subtitle: tr("Data will be reloaded on the next forecast"),
 		})
 	}
 
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/go-alfred"
+)
+
+var rulesFile string
+
+// ruleOptionItems returns the items for removing a rule in the options or,
+// once a rule is entered, adding one. Rules are kept in their own file rather
+// than the config because the watcher reads them too.
+func ruleOptionItems(value string) (items []alfred.Item, err error) {
+	if value == "" {
+		for i, r := range loadRules() {
+			items = append(items, alfred.Item{
+				Title:    r.String(),
+				Subtitle: tr("Press Enter to remove this rule"),
+				Arg: &alfred.ItemArg{
+					Keyword: "options",
+					Mode:    alfred.ModeDo,
+					Data:    alfred.Stringify(&optionsCfg{Rules: &rulesCfg{Remove: &i}}),
+				},
+			})
+		}
+
+		items = append(items, alfred.Item{
+			Title:    tr("Add a rule"),
+			Subtitle: tr("e.g. \"low < 0C tonight\", \"gust > 60km/h tomorrow\", \"precip >= 70\""),
+		})
+		return
+	}
+
+	var r rule
+	if r, err = parseRule(value); err != nil {
+		items = append(items, alfred.Item{
+			Title:    tr("Invalid rule"),
+			Subtitle: err.Error(),
+			Icon:     "error.png",
+		})
+		return items, nil
+	}
+
+	items = append(items, alfred.Item{
+		Title:    tr("Add rule: %s", r.String()),
+		Subtitle: tr("Matches will be shown in the daily forecast and reported by the watcher"),
+		Arg: &alfred.ItemArg{
+			Keyword: "options",
+			Mode:    alfred.ModeDo,
+			Data:    alfred.Stringify(&optionsCfg{Rules: &rulesCfg{Add: &r}}),
+		},
+	})
+
+	return
+}
+
+// rulesItem returns the options item for the rules
+func rulesItem() alfred.Item {
+	return alfred.Item{
+		Title:        fmt.Sprintf("Rules: %d", len(loadRules())),
+		Subtitle:     tr("Forecast conditions to be notified about"),
+		Autocomplete: "Rules ",
+	}
+}
+
+// updateRules adds or removes a rule
+func updateRules(cfg rulesCfg) (out string, err error) {
+	rules := loadRules()
+
+	switch {
+	case cfg.Add != nil:
+		rules = append(rules, *cfg.Add)
+		out = tr("Added rule")
+	case cfg.Remove != nil && *cfg.Remove >= 0 && *cfg.Remove < len(rules):
+		rules = append(rules[:*cfg.Remove], rules[*cfg.Remove+1:]...)
+		out = tr("Removed rule")
+	default:
+		return
+	}
+
+	if err = saveRules(rules); err != nil {
+		return tr("Error saving rules"), err
+	}
+
+	return
+}
+
+type rulesCfg struct {
+	Add    *rule `json:",omitempty"`
+	Remove *int  `json:",omitempty"`
+}
+
+// rule is a user-defined condition on forecast values, such as "low < 0C
+// tonight". Values are stored in base units (Celsius, m/s, mm, or percent).
+type rule struct {
+	Field string  `json:"field"`
+	Op    string  `json:"op"`
+	Value float64 `json:"value"`
+	When  string  `json:"when,omitempty"`
+}
+
+// ruleMatch is a forecast value that matched a rule
+type ruleMatch struct {
+	Rule  rule
+	Time  time.Time
+	Until time.Time
+	Value float64
+}
+
+type ruleKind int
+
+const (
+	kindTemperature ruleKind = iota
+	kindSpeed
+	kindPercent
+	kindAmount
+)
+
+// ruleField is a forecast value that rules can test
+type ruleField struct {
+	label  string
+	kind   ruleKind
+	hourly func(h forecast.Hourly) (float64, bool)
+	daily  func(d forecast.Daily) (float64, bool)
+}
+
+var ruleFields = map[string]ruleField{
+	"low": {
+		label: "low",
+		kind:  kindTemperature,
+		daily: func(d forecast.Daily) (float64, bool) { return float64(d.LowTemp), true },
+	},
+	"high": {
+		label: "high",
+		kind:  kindTemperature,
+		daily: func(d forecast.Daily) (float64, bool) { return float64(d.HighTemp), true },
+	},
+	"temp": {
+		label:  "temperature",
+		kind:   kindTemperature,
+		hourly: func(h forecast.Hourly) (float64, bool) { return float64(h.Temp), true },
+	},
+	"feels": {
+		label:  "feels like",
+		kind:   kindTemperature,
+		hourly: func(h forecast.Hourly) (float64, bool) { return float64(h.ApparentTemp), true },
+	},
+	"wind": {
+		label:  "wind",
+		kind:   kindSpeed,
+		hourly: func(h forecast.Hourly) (float64, bool) { return float64(h.WindSpeed), h.WindSpeed > 0 },
+		daily:  func(d forecast.Daily) (float64, bool) { return float64(d.WindSpeed), d.WindSpeed > 0 },
+	},
+	"gust": {
+		label:  "gusts",
+		kind:   kindSpeed,
+		hourly: func(h forecast.Hourly) (float64, bool) { return float64(h.WindGust), h.WindGust > 0 },
+		daily:  func(d forecast.Daily) (float64, bool) { return float64(d.WindGust), d.WindGust > 0 },
+	},
+	"precip": {
+		label:  "precip chance",
+		kind:   kindPercent,
+		hourly: func(h forecast.Hourly) (float64, bool) { return float64(h.Precip), h.Precip != -1 },
+		daily:  func(d forecast.Daily) (float64, bool) { return float64(d.Precip), d.Precip != -1 },
+	},
+	"rain": {
+		label:  "precip",
+		kind:   kindAmount,
+		hourly: func(h forecast.Hourly) (float64, bool) { return float64(h.PrecipAmount), true },
+		daily:  func(d forecast.Daily) (float64, bool) { return float64(d.PrecipAmount), true },
+	},
+}
+
+var ruleFieldAliases = map[string]string{
+	"min":         "low",
+	"max":         "high",
+	"temperature": "temp",
+	"feelslike":   "feels",
+	"gusts":       "gust",
+	"chance":      "precip",
+	"snow":        "rain",
+}
+
+var ruleOps = map[string]string{
+	"<":     "<",
+	">":     ">",
+	"<=":    "<=",
+	">=":    ">=",
+	"below": "<",
+	"under": "<",
+	"above": ">",
+	"over":  ">",
+}
+
+var ruleWhens = map[string]bool{
+	"today":    true,
+	"tonight":  true,
+	"tomorrow": true,
+}
+
+var ruleValuePattern = regexp.MustCompile(`^(-?\d+(?:\.\d+)?)(.*)$`)
+
+// parseRule parses a rule like "low < 0C tonight" or "gust > 60 km/h
+// tomorrow". Values without a unit are in the configured units.
+func parseRule(text string) (r rule, err error) {
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) < 3 {
+		return r, fmt.Errorf("Use the form <field> <op> <value> [today|tonight|tomorrow]")
+	}
+
+	r.Field = words[0]
+	if alias, ok := ruleFieldAliases[r.Field]; ok {
+		r.Field = alias
+	}
+	field, ok := ruleFields[r.Field]
+	if !ok {
+		return r, fmt.Errorf("Unknown field %q; use one of %s", words[0], strings.Join(ruleFieldNames(), ", "))
+	}
+
+	if r.Op, ok = ruleOps[words[1]]; !ok {
+		return r, fmt.Errorf("Unknown comparison %q; use <, >, <=, or >=", words[1])
+	}
+
+	rest := words[2:]
+	if ruleWhens[rest[len(rest)-1]] {
+		r.When = rest[len(rest)-1]
+		rest = rest[:len(rest)-1]
+	}
+
+	m := ruleValuePattern.FindStringSubmatch(strings.Join(rest, ""))
+	if m == nil {
+		return r, fmt.Errorf("Invalid value %q", strings.Join(rest, " "))
+	}
+	value, _ := strconv.ParseFloat(m[1], 64)
+	unit := strings.TrimPrefix(m[2], "°")
+
+	switch field.kind {
+	case kindTemperature:
+		u := config.TemperatureUnit
+		switch unit {
+		case "":
+		case "c":
+			u = forecast.Celsius
+		case "f":
+			u = forecast.Fahrenheit
+		case "k":
+			u = forecast.Kelvin
+		default:
+			return r, fmt.Errorf("Unknown temperature unit %q", m[2])
+		}
+		r.Value = float64(forecast.TemperatureFrom(value, u))
+
+	case kindSpeed:
+		u := config.WindUnit
+		switch unit {
+		case "":
+		case "km/h", "kmh", "kph":
+			u = forecast.KilometersPerHour
+		case "m/s", "ms":
+			u = forecast.MetersPerSecond
+		case "mph":
+			u = forecast.MilesPerHour
+		case "kn", "kt", "knots":
+			u = forecast.Knots
+		case "bft", "beaufort":
+			u = forecast.Beaufort
+		default:
+			return r, fmt.Errorf("Unknown wind speed unit %q", m[2])
+		}
+		r.Value = float64(forecast.SpeedFrom(value, u))
+
+	case kindAmount:
+		u := config.PrecipUnit
+		switch unit {
+		case "":
+		case "mm":
+			u = forecast.Millimeters
+		case "in":
+			u = forecast.Inches
+		default:
+			return r, fmt.Errorf("Unknown precipitation unit %q", m[2])
+		}
+		r.Value = float64(forecast.PrecipitationFrom(value, u))
+
+	case kindPercent:
+		if unit != "" && unit != "%" {
+			return r, fmt.Errorf("Precipitation chance must be a percentage")
+		}
+		r.Value = value
+	}
+
+	return
+}
+
+func ruleFieldNames() []string {
+	return []string{"low", "high", "temp", "feels", "wind", "gust", "precip", "rain"}
+}
+
+// format returns a value for a rule's field in a config's units
+func (r rule) format(c *configStruct, value float64) string {
+	switch ruleFields[r.Field].kind {
+	case kindTemperature:
+		return c.formatTemperature(forecast.Temperature(value))
+	case kindSpeed:
+		return c.formatSpeed(forecast.Speed(value))
+	case kindAmount:
+		return c.formatPrecipitation(forecast.Precipitation(value))
+	}
+	return forecast.FormatNumber(value, 0) + "%"
+}
+
+// String returns a description of a rule in the configured units
+func (r rule) String() string {
+	return r.describe(&config)
+}
+
+// describe returns a description of a rule in a config's units
+func (r rule) describe(c *configStruct) string {
+	s := fmt.Sprintf("%s %s %s", ruleFields[r.Field].label, r.Op, r.format(c, r.Value))
+	if r.When != "" {
+		s += " " + r.When
+	}
+	return s
+}
+
+func (r rule) matches(value float64) bool {
+	switch r.Op {
+	case "<":
+		return value < r.Value
+	case "<=":
+		return value <= r.Value
+	case ">":
+		return value > r.Value
+	case ">=":
+		return value >= r.Value
+	}
+	return false
+}
+
+// window returns the time span a rule applies to
+func (r rule) window(weather *forecast.Weather, now time.Time) (start, end time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch r.When {
+	case "today":
+		return now, today.AddDate(0, 0, 1)
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), today.AddDate(0, 0, 2)
+	case "tonight":
+		start = today.Add(18 * time.Hour)
+		end = today.AddDate(0, 0, 1).Add(8 * time.Hour)
+		for i, d := range weather.Daily {
+			if sameDay(d.Date, today) {
+				start = d.Sunset
+				if i+1 < len(weather.Daily) {
+					end = weather.Daily[i+1].Sunrise
+				}
+			}
+		}
+		if start.Before(now) {
+			start = now
+		}
+		return
+	}
+
+	return now, now.AddDate(1, 0, 0)
+}
+
+// evaluate returns the first forecast value that matches the rule, if any
+func (r rule) evaluate(weather *forecast.Weather, now time.Time) (match ruleMatch, ok bool) {
+	field, known := ruleFields[r.Field]
+	if !known {
+		return
+	}
+
+	start, end := r.window(weather, now)
+
+	if field.kind == kindAmount {
+		// Amounts add up, so they're compared as totals: the sum of the hourly
+		// amounts for tonight, which is only part of a day, and the daily
+		// amounts otherwise
+		if r.When == "tonight" {
+			if total, at, found := r.hourlyTotal(weather, field, start, end); found {
+				if r.matches(total) {
+					return ruleMatch{Rule: r, Time: at, Until: end, Value: total}, true
+				}
+				return
+			}
+		}
+	} else if field.hourly != nil {
+		found := false
+		for _, h := range weather.Hourly {
+			if h.Time.Before(start.Truncate(time.Hour)) || !h.Time.Before(end) {
+				continue
+			}
+			value, valid := field.hourly(h)
+			if !valid {
+				continue
+			}
+			found = true
+			if r.matches(value) {
+				return ruleMatch{Rule: r, Time: h.Time, Until: end, Value: value}, true
+			}
+		}
+		if found || field.daily == nil {
+			return
+		}
+	}
+
+	for _, d := range weather.Daily {
+		day := time.Date(d.Date.Year(), d.Date.Month(), d.Date.Day(), 0, 0, 0, 0, now.Location())
+		inWindow := !day.Before(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, now.Location())) && day.Before(end)
+		if r.When == "tonight" {
+			inWindow = sameDay(d.Date, now)
+		}
+		if !inWindow {
+			continue
+		}
+		value, valid := field.daily(d)
+		if valid && r.matches(value) {
+			until := day.AddDate(0, 0, 1)
+			if until.After(end) {
+				until = end
+			}
+			return ruleMatch{Rule: r, Time: day, Until: until, Value: value}, true
+		}
+	}
+
+	return
+}
+
+// hourlyTotal returns the sum of a field's hourly values in a window and the
+// time of the first hour, and whether any hours of the window are forecast
+func (r rule) hourlyTotal(weather *forecast.Weather, field ruleField, start, end time.Time) (total float64, at time.Time, found bool) {
+	for _, h := range weather.Hourly {
+		if h.Time.Before(start.Truncate(time.Hour)) || !h.Time.Before(end) {
+			continue
+		}
+		value, valid := field.hourly(h)
+		if !valid {
+			continue
+		}
+		if !found {
+			at = h.Time
+			found = true
+		}
+		total += value
+	}
+	return
+}
+
+// evaluateRules returns the matches for a list of rules
+func evaluateRules(rules []rule, weather *forecast.Weather, now time.Time) (matches []ruleMatch) {
+	for _, r := range rules {
+		if m, ok := r.evaluate(weather, now); ok {
+			matches = append(matches, m)
+		}
+	}
+	return
+}
+
+// alert returns an alert describing a rule match
+func (m ruleMatch) alert() forecast.Alert {
+	return forecast.Alert{
+		Title:       tr("Rule matched: %s", m.Rule),
+		Description: fmt.Sprintf("%s at %s", m.Rule.format(&config, m.Value), m.Time.Format(config.TimeFormat+", "+config.DateFormat)),
+		Severity:    forecast.SeverityAdvisory,
+		Onset:       m.Time,
+		Expires:     m.Until,
+		Sender:      "alfred-weather",
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+func loadRules() (rules []rule) {
+	if err := alfred.LoadJSON(rulesFile, &rules); err != nil {
+		dlog.Printf("No rules loaded: %v", err)
+	}
+	return
+}
+
+func saveRules(rules []rule) error {
+	return alfred.SaveJSON(rulesFile, &rules)
+}
+
+// ruleItems returns an item for each rule that matches the forecast at a
+// given time. Text is formatted according to c.
+func ruleItems(c *configStruct, rules []rule, weather *forecast.Weather, now time.Time) (items []alfred.Item) {
+	for _, m := range evaluateRules(rules, weather, now) {
+		items = append(items, alfred.Item{
+			Title:    c.tr("Rule matched: %s", m.Rule.describe(c)),
+			Subtitle: fmt.Sprintf("%s  ·  %s %s", m.Rule.format(c, m.Value), c.shortWeekday(m.Time), m.Time.Format(c.TimeFormat)),
+			Icon:     "notice.png",
+			Arg:      optionsArg,
+		})
+	}
+	return
+}
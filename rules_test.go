@@ -0,0 +1,103 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// rainyWeather returns a forecast with 2 mm of rain in every hour and an
+// unknown hourly chance of rain
+func rainyWeather(now time.Time) *forecast.Weather {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weather := &forecast.Weather{
+		Daily: []forecast.Daily{
+			{Date: today, Sunrise: today.Add(7 * time.Hour), Sunset: today.Add(18 * time.Hour), Precip: -1, PrecipAmount: 10},
+			{Date: today.AddDate(0, 0, 1), Sunrise: today.Add(31 * time.Hour), Sunset: today.Add(42 * time.Hour), Precip: 10, PrecipAmount: 48},
+		},
+	}
+	for i := 0; i < 48; i++ {
+		weather.Hourly = append(weather.Hourly, forecast.Hourly{Time: now.Add(time.Duration(i) * time.Hour), Precip: -1, PrecipAmount: 2})
+	}
+	return weather
+}
+
+func TestRuleEvaluate(t *testing.T) {
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	tomorrow := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule  string
+		match bool
+		time  time.Time
+		value float64
+	}{
+		// a day's total, not a single hour's amount
+		{"rain > 20mm tomorrow", true, tomorrow, 48},
+		{"rain > 20mm today", false, time.Time{}, 0},
+		// 13 hours from sunset to sunrise
+		{"rain > 20mm tonight", true, now.Add(9 * time.Hour), 26},
+		{"rain > 30mm tonight", false, time.Time{}, 0},
+		// an unknown chance never matches, and the daily chance is used
+		// when the hourly chance is unknown
+		{"precip < 5", false, time.Time{}, 0},
+		{"precip < 20", true, tomorrow, 10},
+	}
+
+	for _, test := range tests {
+		r, err := parseRule(test.rule)
+		if err != nil {
+			t.Fatalf("%q: %v", test.rule, err)
+		}
+		m, ok := r.evaluate(rainyWeather(now), now)
+		if ok != test.match {
+			t.Errorf("%q: matched = %v, want %v", test.rule, ok, test.match)
+			continue
+		}
+		if ok && (!m.Time.Equal(test.time) || m.Value != test.value) {
+			t.Errorf("%q: matched %v at %v, want %v at %v", test.rule, m.Value, m.Time, test.value, test.time)
+		}
+	}
+}
+
+func TestRuleOptions(t *testing.T) {
+	defer func(c configStruct, f string) { config, rulesFile = c, f }(config, rulesFile)
+	config.Language = "de"
+	config.TemperatureUnit = forecast.Celsius
+	rulesFile = filepath.Join(t.TempDir(), "rules.json")
+
+	items, err := OptionsCommand{}.Items("Rules low < 0C tonight", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Title != "Regel hinzufügen: low < 0°C tonight" || items[0].Arg == nil {
+		t.Fatalf("items = %+v, want one translated item that adds the rule", items)
+	}
+
+	out, err := OptionsCommand{}.Do(items[0].Arg.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Regel hinzugefügt" {
+		t.Errorf("output = %q, want a translated confirmation", out)
+	}
+	if rules := loadRules(); len(rules) != 1 || rules[0].Field != "low" {
+		t.Fatalf("rules = %v, want the added rule", rules)
+	}
+
+	// The rule is listed with an item to remove it
+	if items, err = (OptionsCommand{}).Items("Rules ", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Subtitle != "Enter drücken, um diese Regel zu entfernen" {
+		t.Fatalf("items = %+v, want the rule and an item to add one", items)
+	}
+	if _, err = (OptionsCommand{}).Do(items[0].Arg.Data); err != nil {
+		t.Fatal(err)
+	}
+	if rules := loadRules(); len(rules) != 0 {
+		t.Errorf("rules = %v, want none after removing it", rules)
+	}
+}
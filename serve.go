@@ -0,0 +1,430 @@
+package main
+
+import (
+	"container/list"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/cache"
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/alfred-weather/providers"
+)
+
+const (
+	// serverCacheSize is the number of queries and forecasts the server keeps
+	// in memory
+	serverCacheSize = 256
+
+	// serverLocationTTL is how long a geocoded query is kept
+	serverLocationTTL = 24 * time.Hour
+
+	// serverRequestRate is the number of requests per second the server
+	// answers over time, and serverRequestBurst the number it answers at once
+	serverRequestRate  = 1.0
+	serverRequestBurst = 10
+)
+
+// forecastServer serves forecasts over HTTP. Requests are handled one at a
+// time, since the config and cache are shared, which also means that
+// concurrent requests for the same location only reach the provider once.
+type forecastServer struct {
+	mu sync.Mutex
+
+	// locations holds geocoded queries
+	locations *lruCache
+
+	// forecasts holds weather for locations that aren't in the persistent
+	// cache, keyed by location name
+	forecasts *lruCache
+
+	// limiter limits how often clients can make requests, since each one may
+	// reach the geocoder or the weather provider
+	limiter *rateLimiter
+}
+
+func newForecastServer() *forecastServer {
+	return &forecastServer{
+		locations: newLRUCache(serverCacheSize),
+		forecasts: newLRUCache(serverCacheSize),
+		limiter:   newRateLimiter(serverRequestRate, serverRequestBurst),
+	}
+}
+
+// lruCache is a size-bounded cache that drops the least recently used entry
+// when it's full. It isn't safe for concurrent use.
+type lruCache struct {
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+	added time.Time
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// get returns the value for a key if it was added within maxAge of now. A
+// maxAge of 0 means entries don't expire.
+func (c *lruCache) get(key string, now time.Time, maxAge time.Duration) (value interface{}, ok bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if maxAge > 0 && now.Sub(entry.added) > maxAge {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// add stores a value, dropping the least recently used entry if the cache is
+// full
+func (c *lruCache) add(key string, value interface{}, now time.Time) {
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &lruEntry{key: key, value: value, added: now}
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, added: now})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// len returns the number of entries in the cache
+func (c *lruCache) len() int {
+	return c.order.Len()
+}
+
+// rateLimiter is a token bucket that allows burst requests at once and rate
+// requests per second over time
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// allow reports whether a request made at now may proceed
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// jsonCurrentDocument is the response for /v1/current
+type jsonCurrentDocument struct {
+	Version   int          `json:"version"`
+	Generated time.Time    `json:"generated"`
+	Provider  jsonProvider `json:"provider"`
+	Location  jsonLocation `json:"location"`
+	Current   jsonCurrent  `json:"current"`
+}
+
+// jsonAlertsDocument is the response for /v1/alerts
+type jsonAlertsDocument struct {
+	Version   int                  `json:"version"`
+	Generated time.Time            `json:"generated"`
+	Locations []jsonLocationAlerts `json:"locations"`
+}
+
+type jsonLocationAlerts struct {
+	Location jsonLocation `json:"location"`
+	Alerts   []jsonAlert  `json:"alerts"`
+}
+
+// jsonError is the response for a failed request
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+// location returns the location for a query, or the default location if the
+// query is empty
+func (s *forecastServer) location(query string, now time.Time) (loc forecast.Location, err error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return config.Location, nil
+	}
+
+	if loc, ok := parseCoordinates(query); ok {
+		return loc, nil
+	}
+
+	key := strings.ToLower(query)
+	if cached, ok := s.locations.get(key, now, serverLocationTTL); ok {
+		return cached.(forecast.Location), nil
+	}
+
+	if loc, err = locate(query); err != nil {
+		return
+	}
+	s.locations.add(key, loc, now)
+	return
+}
+
+// forecast returns the forecast for a location, using the persistent cache
+// for the default and saved locations and an in-memory cache for others
+func (s *forecastServer) forecast(loc forecast.Location, now time.Time) (weather forecast.Weather, err error) {
+	if loc.Name == config.Location.Name || isSavedLocation(loc) {
+		return getForecast(loc, now)
+	}
+
+	if cached, ok := s.forecasts.get(loc.Name, now, 0); ok {
+		if entry := cached.(cache.Entry); !entry.Expired(now, config.Service) {
+			dlog.Printf("Using in-memory weather for %s", loc.Name)
+			return entry.Weather, nil
+		}
+	}
+
+	if weather, err = getForecast(loc, now); err != nil {
+		return
+	}
+	s.forecasts.add(loc.Name, cache.Entry{Weather: weather, Time: now, Service: config.Service}, now)
+	return
+}
+
+// weather returns the location and forecast for a query
+func (s *forecastServer) weather(query string, now time.Time) (loc forecast.Location, weather forecast.Weather, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = validateConfig(); err != nil {
+		return
+	}
+
+	if loc, err = s.location(query, now); err != nil {
+		return
+	}
+
+	weather, err = s.forecast(loc, now)
+	return
+}
+
+// document returns the JSON forecast document for a query
+func (s *forecastServer) document(query string, now time.Time) (doc jsonForecast, err error) {
+	var loc forecast.Location
+	var weather forecast.Weather
+	if loc, weather, err = s.weather(query, now); err != nil {
+		return
+	}
+	return newJSONForecast(loc, weather, now), nil
+}
+
+// locationDocument returns the JSON forecast document for a location. The
+// caller must hold the server's lock.
+func (s *forecastServer) locationDocument(loc forecast.Location, now time.Time) (doc jsonForecast, err error) {
+	var weather forecast.Weather
+	if weather, err = s.forecast(loc, now); err != nil {
+		return
+	}
+	return newJSONForecast(loc, weather, now), nil
+}
+
+// alertsDocument returns the alerts for a query, or for the default and saved
+// locations if the query is empty
+func (s *forecastServer) alertsDocument(query string, now time.Time) (result jsonAlertsDocument, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = validateConfig(); err != nil {
+		return
+	}
+
+	locations := allLocations()
+	if query != "" {
+		var loc forecast.Location
+		if loc, err = s.location(query, now); err != nil {
+			return
+		}
+		locations = []forecast.Location{loc}
+	}
+
+	result = jsonAlertsDocument{
+		Version:   jsonSchemaVersion,
+		Generated: now,
+		Locations: []jsonLocationAlerts{},
+	}
+	for _, loc := range locations {
+		var doc jsonForecast
+		if doc, err = s.locationDocument(loc, now); err != nil {
+			return
+		}
+		result.Locations = append(result.Locations, jsonLocationAlerts{
+			Location: doc.Location,
+			Alerts:   doc.Alerts,
+		})
+	}
+
+	return
+}
+
+// handler returns the server's HTTP handler
+func (s *forecastServer) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+		doc, err := s.document(r.URL.Query().Get("q"), clock.Now())
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		writeHTTPJSON(w, http.StatusOK, doc)
+	})
+
+	mux.HandleFunc("/v1/current", func(w http.ResponseWriter, r *http.Request) {
+		doc, err := s.document(r.URL.Query().Get("q"), clock.Now())
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		writeHTTPJSON(w, http.StatusOK, jsonCurrentDocument{
+			Version:   doc.Version,
+			Generated: doc.Generated,
+			Provider:  doc.Provider,
+			Location:  doc.Location,
+			Current:   doc.Current,
+		})
+	})
+
+	mux.HandleFunc("/v1/alerts", func(w http.ResponseWriter, r *http.Request) {
+		doc, err := s.alertsDocument(r.URL.Query().Get("q"), clock.Now())
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		writeHTTPJSON(w, http.StatusOK, doc)
+	})
+
+	mux.HandleFunc("/v1/calendar.ics", func(w http.ResponseWriter, r *http.Request) {
+		now := clock.Now()
+		loc, weather, err := s.weather(r.URL.Query().Get("q"), now)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		if err := writeICalendar(w, loc, weather, now); err != nil {
+			dlog.Printf("Unable to write response: %v", err)
+		}
+	})
+
+	mux.HandleFunc("/metrics", s.handleMetrics)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeHTTPJSON(w, http.StatusNotFound, jsonError{Error: "Not found"})
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.limiter.allow(clock.Now()) {
+			w.Header().Set("Retry-After", "1")
+			writeHTTPJSON(w, http.StatusTooManyRequests, jsonError{Error: "Too many requests"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// writeHTTPJSON writes a JSON response
+func writeHTTPJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := writeJSON(w, v); err != nil {
+		dlog.Printf("Unable to write response: %v", err)
+	}
+}
+
+// writeHTTPError writes an error response with a status that reflects the
+// kind of error
+func writeHTTPError(w http.ResponseWriter, err error) {
+	var keyErr *providers.InvalidKeyError
+	var quotaErr *providers.QuotaError
+	var notFoundErr *providers.NotFoundError
+	var locationErr locationNotFoundError
+	var budgetErr quotaError
+
+	status := http.StatusBadGateway
+	switch {
+	case errors.As(err, &locationErr), errors.As(err, &notFoundErr):
+		status = http.StatusNotFound
+	case errors.As(err, &keyErr), errors.As(err, &quotaErr), errors.As(err, &budgetErr), validateConfig() != nil:
+		status = http.StatusServiceUnavailable
+	}
+	writeHTTPJSON(w, status, jsonError{Error: err.Error()})
+}
+
+// runServe serves forecasts over HTTP. It returns an exit status.
+func runServe(args []string) int {
+	defaultData, defaultCache := standaloneDirs()
+
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:8080", "address to listen on")
+	dataDir := flags.String("data", defaultData, "directory holding config.json")
+	cacheDir := flags.String("cache", defaultCache, "directory holding cache.json")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := initStandaloneConfig(*dataDir, *cacheDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	if err := validateConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	server := &http.Server{
+		Addr:         *addr,
+		Handler:      newForecastServer().handler(),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: time.Minute,
+	}
+
+	dlog.Printf("Listening on %s", *addr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
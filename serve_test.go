@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/geocoding"
+)
+
+func TestLRUCache(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := newLRUCache(2)
+
+	c.add("a", 1, now)
+	c.add("b", 2, now)
+	if _, ok := c.get("a", now, 0); !ok {
+		t.Fatal("a was dropped early")
+	}
+
+	// b is now the least recently used entry
+	c.add("c", 3, now)
+	if c.len() != 2 {
+		t.Fatalf("cache holds %d entries, want 2", c.len())
+	}
+	if _, ok := c.get("b", now, 0); ok {
+		t.Error("b wasn't dropped")
+	}
+	if v, ok := c.get("a", now, 0); !ok || v.(int) != 1 {
+		t.Errorf("a = %v, %v; want 1, true", v, ok)
+	}
+
+	later := now.Add(2 * time.Hour)
+	if _, ok := c.get("c", later, time.Hour); ok {
+		t.Error("c didn't expire")
+	}
+	if c.len() != 1 {
+		t.Errorf("expired entry wasn't removed; cache holds %d entries", c.len())
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if !l.allow(now) {
+			t.Fatalf("request %d was refused within the burst", i+1)
+		}
+	}
+	if l.allow(now) {
+		t.Fatal("request beyond the burst was allowed")
+	}
+	if !l.allow(now.Add(time.Second)) {
+		t.Fatal("request was refused after the bucket refilled")
+	}
+	if l.allow(now.Add(time.Second)) {
+		t.Fatal("bucket refilled too quickly")
+	}
+}
+
+func TestServerLocationCoordinates(t *testing.T) {
+	saved := locateGeocodes
+	defer func() { locateGeocodes = saved }()
+	locateGeocodes = func(location string) ([]geocoding.Geocode, error) {
+		return nil, errors.New("geocoder called for " + location)
+	}
+
+	loc, err := newForecastServer().location("52.5,13.4", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Latitude != 52.5 || loc.Longitude != 13.4 {
+		t.Errorf("location = %v, %v; want 52.5, 13.4", loc.Latitude, loc.Longitude)
+	}
+}
+
+func TestServerRateLimit(t *testing.T) {
+	s := newForecastServer()
+	s.limiter = newRateLimiter(0, 1)
+	h := s.handler()
+
+	for i, want := range []int{http.StatusNotFound, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/unknown", nil))
+		if rec.Code != want {
+			t.Errorf("request %d: status = %d, want %d", i+1, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/go-alfred"
+)
+
+// settingKind is the type of a setting's value
+type settingKind int
+
+const (
+	// textSetting is free text
+	textSetting settingKind = iota
+
+	// choiceSetting is one of a list of choices
+	choiceSetting
+
+	// flagSetting is on or off
+	flagSetting
+
+	// numberSetting is a whole number
+	numberSetting
+
+	// locationSetting is a place, found by looking up a name
+	locationSetting
+)
+
+// choice is one of the values of a choiceSetting
+type choice struct {
+	value    string
+	subtitle string
+	icon     string
+}
+
+// accessor reads and writes a setting's config field as a string
+type accessor struct {
+	get func(c *configStruct) string
+	set func(c *configStruct, value string) error
+}
+
+// setting describes a config option. The options command and the cli's set
+// command are both generated from the settings list.
+type setting struct {
+	accessor
+
+	name string
+	desc string
+	kind settingKind
+
+	// choices lists the values of a choiceSetting. If it returns no choices,
+	// any value is accepted.
+	choices func() []choice
+
+	// validate checks a new value before it's saved, returning a note about
+	// the value to show the user
+	validate func(value string) (note string, err error)
+
+	// def is the value of an unset option
+	def string
+
+	// dependsOn indicates whether the setting applies to a config; settings
+	// that don't apply aren't shown in the options
+	dependsOn func(c *configStruct) bool
+
+	// display returns the current value for the options list, if it should be
+	// shown differently than the stored value
+	display func(c *configStruct) string
+
+	// values returns the items shown while entering a value, for settings
+	// that need more than the default items
+	values func(s *setting, value string) ([]alfred.Item, error)
+}
+
+// settings are the config options, in the order they're shown
+var settings = []setting{
+	{
+		name:     "Service",
+		desc:     "Service to use",
+		kind:     choiceSetting,
+		accessor: textField(func(c *configStruct) *string { return &c.Service }),
+		choices:  valueChoices(serviceDarkSky, serviceOpenWeather, serviceClimaCell),
+	},
+	keySetting("DarkSkyKey", "Your API key for Dark Sky", serviceDarkSky, func(c *configStruct) *string { return &c.DarkSkyKey }),
+	keySetting("OpenWeatherKey", "Your API key for OpenWeather", serviceOpenWeather, func(c *configStruct) *string { return &c.OpenWeatherKey }),
+	keySetting("ClimaCellKey", "Your API key for ClimaCell", serviceClimaCell, func(c *configStruct) *string { return &c.ClimaCellKey }),
+	{
+		name:     "Icons",
+		desc:     "Icon set",
+		kind:     choiceSetting,
+		accessor: textField(func(c *configStruct) *string { return &c.Icons }),
+		choices:  iconChoices,
+		def:      "grzanka",
+	},
+	{
+		name:     "DateFormat",
+		desc:     "Date format",
+		kind:     choiceSetting,
+		accessor: textField(func(c *configStruct) *string { return &c.DateFormat }),
+		choices:  valueChoices(DateFormats...),
+		def:      DateFormats[0],
+	},
+	{
+		name:     "TimeFormat",
+		desc:     "Time format",
+		kind:     choiceSetting,
+		accessor: textField(func(c *configStruct) *string { return &c.TimeFormat }),
+		choices:  valueChoices(TimeFormats...),
+		def:      TimeFormats[0],
+	},
+	{
+		name:     "Language",
+		desc:     "Language",
+		kind:     choiceSetting,
+		accessor: textField(func(c *configStruct) *string { return &c.Language }),
+		choices:  languageChoices,
+		def:      defaultLanguage,
+		display:  func(c *configStruct) string { return languageNames[c.Language] },
+	},
+	{
+		name:     "ShowDecimals",
+		desc:     "Show temperatures with one decimal place",
+		kind:     flagSetting,
+		accessor: flagField(func(c *configStruct) *bool { return &c.ShowDecimals }),
+	},
+	{
+		name:      "DailyBudget",
+		desc:      "Daily request budget for the service (0 for its free-tier limit)",
+		kind:      numberSetting,
+		accessor:  numberField(func(c *configStruct) *int { return &c.DailyBudget }),
+		validate:  nonNegative,
+		dependsOn: func(c *configStruct) bool { return c.Service != "" },
+	},
+	{
+		name: "Location",
+		desc: "Default location",
+		kind: locationSetting,
+		accessor: accessor{
+			get: func(c *configStruct) string { return c.Location.Name },
+			set: func(c *configStruct, value string) (err error) {
+				c.Location, err = locate(value)
+				return
+			},
+		},
+		values: locationItems,
+	},
+	{
+		name: "SavedLocations",
+		desc: "Saved locations",
+		kind: locationSetting,
+		accessor: accessor{
+			get: func(c *configStruct) string { return strconv.Itoa(len(c.SavedLocations)) },
+			set: func(c *configStruct, value string) error {
+				loc, err := locate(value)
+				if err == nil {
+					c.SavedLocations = append(append([]forecast.Location{}, c.SavedLocations...), loc)
+				}
+				return err
+			},
+		},
+		values: savedLocationItems,
+	},
+	{
+		name: "Units",
+		desc: "Units preset",
+		kind: choiceSetting,
+		accessor: accessor{
+			get: func(c *configStruct) string { return string(c.Units) },
+			set: func(c *configStruct, value string) error {
+				c.setUnits(unitPresets[units(value)])
+				return nil
+			},
+		},
+		choices: presetChoices,
+	},
+	unitSetting("TemperatureUnit", "Temperature units", accessor{
+		get: func(c *configStruct) string { return string(c.TemperatureUnit) },
+		set: func(c *configStruct, value string) error {
+			c.TemperatureUnit = forecast.TemperatureUnit(value)
+			return nil
+		},
+	}),
+	unitSetting("WindUnit", "Wind speed units", accessor{
+		get: func(c *configStruct) string { return string(c.WindUnit) },
+		set: func(c *configStruct, value string) error {
+			c.WindUnit = forecast.WindUnit(value)
+			return nil
+		},
+	}),
+	unitSetting("PressureUnit", "Pressure units", accessor{
+		get: func(c *configStruct) string { return string(c.PressureUnit) },
+		set: func(c *configStruct, value string) error {
+			c.PressureUnit = forecast.PressureUnit(value)
+			return nil
+		},
+	}),
+	unitSetting("PrecipUnit", "Precipitation units", accessor{
+		get: func(c *configStruct) string { return string(c.PrecipUnit) },
+		set: func(c *configStruct, value string) error {
+			c.PrecipUnit = forecast.PrecipUnit(value)
+			return nil
+		},
+	}),
+	unitSetting("DistanceUnit", "Distance units", accessor{
+		get: func(c *configStruct) string { return string(c.DistanceUnit) },
+		set: func(c *configStruct, value string) error {
+			c.DistanceUnit = forecast.DistanceUnit(value)
+			return nil
+		},
+	}),
+}
+
+// findSetting returns the setting with a given name, or nil
+func findSetting(name string) *setting {
+	for i := range settings {
+		if settings[i].name == name {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// applyDefaults fills in the default value of each unset option
+func applyDefaults(c *configStruct) {
+	for _, s := range settings {
+		if s.def != "" && s.get(c) == "" {
+			s.set(c, s.def)
+		}
+	}
+	c.setUnits(c.units().withDefaults(c.Units))
+}
+
+// applies indicates whether a setting applies to a config
+func (s *setting) applies(c *configStruct) bool {
+	return s.dependsOn == nil || s.dependsOn(c)
+}
+
+// displayValue returns a setting's current value as it's shown in the options
+func (s *setting) displayValue(c *configStruct) string {
+	if s.display != nil {
+		return s.display(c)
+	}
+	return s.get(c)
+}
+
+// check validates a new value, returning it in its canonical form along with
+// a note to show the user
+func (s *setting) check(value string) (canonical, note string, err error) {
+	canonical = value
+
+	switch s.kind {
+	case choiceSetting:
+		choices := s.choices()
+		if len(choices) == 0 {
+			break
+		}
+		normalize := func(v string) string { return strings.ToLower(strings.Replace(v, " ", "", -1)) }
+		var values []string
+		for _, c := range choices {
+			if normalize(c.value) == normalize(value) {
+				canonical = c.value
+				break
+			}
+			values = append(values, c.value)
+		}
+		if len(values) == len(choices) {
+			return value, "", fmt.Errorf("%s must be one of %s", s.name, strings.Join(values, ", "))
+		}
+
+	case flagSetting:
+		if _, err = strconv.ParseBool(value); err != nil {
+			return value, "", fmt.Errorf("%s must be true or false", s.name)
+		}
+
+	case numberSetting:
+		if _, err = strconv.Atoi(value); err != nil {
+			return value, "", fmt.Errorf("%s must be a number", s.name)
+		}
+	}
+
+	if s.validate != nil {
+		note, err = s.validate(canonical)
+	}
+	return
+}
+
+// update returns a copy of the config with a setting changed
+func (s *setting) update(value string) (opts configStruct, err error) {
+	opts = config
+	err = s.set(&opts, value)
+	return
+}
+
+// arg returns an item arg that saves the config with a setting changed
+func (s *setting) arg(value string) (arg *alfred.ItemArg, err error) {
+	var opts configStruct
+	if opts, err = s.update(value); err != nil {
+		return
+	}
+	return &alfred.ItemArg{
+		Keyword: "options",
+		Mode:    alfred.ModeDo,
+		Data:    alfred.Stringify(&opts),
+	}, nil
+}
+
+// item returns the options list item for a setting
+func (s *setting) item() alfred.Item {
+	if s.kind == flagSetting {
+		on := s.get(&config) == "true"
+		item := alfred.Item{
+			Title:        s.name,
+			Subtitle:     s.desc,
+			Autocomplete: s.name,
+		}
+		item.Arg, _ = s.arg(strconv.FormatBool(!on))
+		item.AddCheckBox(on)
+		return item
+	}
+
+	return alfred.Item{
+		Title:        fmt.Sprintf("%s: %s", s.name, s.displayValue(&config)),
+		Subtitle:     s.desc,
+		Autocomplete: s.name + " ",
+	}
+}
+
+// valueItems returns the items shown while entering a new value for a setting
+func (s *setting) valueItems(value string) (items []alfred.Item, err error) {
+	if s.values != nil {
+		return s.values(s, value)
+	}
+
+	switch s.kind {
+	case choiceSetting:
+		current := s.get(&config)
+		for _, c := range s.choices() {
+			if !alfred.FuzzyMatches(c.value+" "+c.subtitle, value) {
+				continue
+			}
+			item := alfred.Item{
+				Title:    c.value,
+				Subtitle: c.subtitle,
+				Icon:     c.icon,
+			}
+			if item.Arg, err = s.arg(c.value); err != nil {
+				return
+			}
+			item.AddCheckBox(current == c.value)
+			items = append(items, item)
+		}
+
+	case flagSetting:
+		item := s.item()
+		item.Title += " (press Enter to toggle)"
+		items = append(items, item)
+
+	default:
+		item := s.item()
+		if value == "" {
+			item.Title += " (type a new value to change)"
+			return []alfred.Item{item}, nil
+		}
+
+		item.Title = fmt.Sprintf("%s: %s", s.name, value)
+		canonical, note, checkErr := s.check(value)
+		if checkErr != nil {
+			item.Subtitle = checkErr.Error()
+		} else {
+			if note != "" {
+				item.Subtitle = note
+			}
+			if item.Arg, err = s.arg(canonical); err != nil {
+				return
+			}
+		}
+		items = append(items, item)
+	}
+
+	return
+}
+
+// textField accesses a string config field
+func textField(field func(c *configStruct) *string) accessor {
+	return accessor{
+		get: func(c *configStruct) string { return *field(c) },
+		set: func(c *configStruct, value string) error {
+			*field(c) = value
+			return nil
+		},
+	}
+}
+
+// flagField accesses a bool config field
+func flagField(field func(c *configStruct) *bool) accessor {
+	return accessor{
+		get: func(c *configStruct) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *configStruct, value string) (err error) {
+			*field(c), err = strconv.ParseBool(value)
+			return
+		},
+	}
+}
+
+// numberField accesses an int config field
+func numberField(field func(c *configStruct) *int) accessor {
+	return accessor{
+		get: func(c *configStruct) string { return strconv.Itoa(*field(c)) },
+		set: func(c *configStruct, value string) (err error) {
+			*field(c), err = strconv.Atoi(value)
+			return
+		},
+	}
+}
+
+// keySetting describes the API key option of a service. It's only shown
+// while the service is selected, and keys are checked with the service before
+// they're saved.
+func keySetting(name, desc, service string, field func(c *configStruct) *string) setting {
+	return setting{
+		name:     name,
+		desc:     desc,
+		kind:     textSetting,
+		accessor: textField(field),
+		validate: func(value string) (string, error) {
+			note, ok := checkKey(service, value)
+			if !ok {
+				return "", errors.New(note)
+			}
+			return note, nil
+		},
+		dependsOn: func(c *configStruct) bool { return c.Service == service },
+	}
+}
+
+// unitSetting describes the option for the units of one measured quantity
+func unitSetting(name, desc string, access accessor) setting {
+	return setting{
+		name:     name,
+		desc:     desc,
+		kind:     choiceSetting,
+		accessor: access,
+		choices:  func() []choice { return valueChoices(unitChoices(name)...)() },
+	}
+}
+
+// valueChoices returns a fixed list of choices
+func valueChoices(values ...string) func() []choice {
+	return func() (choices []choice) {
+		for _, v := range values {
+			choices = append(choices, choice{value: v})
+		}
+		return
+	}
+}
+
+// languageChoices lists the UI languages with their names
+func languageChoices() (choices []choice) {
+	for _, lang := range Languages {
+		choices = append(choices, choice{value: lang, subtitle: languageNames[lang]})
+	}
+	return
+}
+
+// iconChoices lists the installed icon sets
+func iconChoices() (choices []choice) {
+	dirs, err := ioutil.ReadDir("icons")
+	if err != nil {
+		dlog.Printf("Unable to list icon sets: %v", err)
+		return
+	}
+	for _, dir := range dirs {
+		if dir.IsDir() {
+			choices = append(choices, choice{value: dir.Name(), icon: path.Join("icons", dir.Name(), "tstorms.png")})
+		}
+	}
+	return
+}
+
+// presetChoices lists the units presets, with the units of each
+func presetChoices() (choices []choice) {
+	for _, preset := range []units{unitsMetric, unitsUS} {
+		u := unitPresets[preset]
+		choices = append(choices, choice{
+			value:    string(preset),
+			subtitle: fmt.Sprintf("%s, %s, %s, %s, %s", u.Temperature.Symbol(), u.Wind, u.Pressure, u.Precip, u.Distance),
+		})
+	}
+	return
+}
+
+// nonNegative checks that a number isn't negative
+func nonNegative(value string) (string, error) {
+	if n, _ := strconv.Atoi(value); n < 0 {
+		return "", fmt.Errorf("Value can't be negative")
+	}
+	return "", nil
+}
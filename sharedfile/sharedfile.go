@@ -0,0 +1,110 @@
+// Package sharedfile updates files that several processes write, such as the
+// usage and cache files shared by Alfred and the long-running commands.
+package sharedfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// lockRetry is how often a held lock is retried
+	lockRetry = 10 * time.Millisecond
+
+	// lockTimeout is how long to wait for a held lock before giving up
+	lockTimeout = 2 * time.Second
+
+	// lockStale is how old a lock can be before it's assumed to have been
+	// left behind by a process that exited while holding it
+	lockStale = 10 * time.Second
+)
+
+// LockedError is returned when a file stays locked by another process
+type LockedError struct {
+	Path string
+}
+
+func (e *LockedError) Error() string {
+	return "Timed out waiting for lock on " + e.Path
+}
+
+// Update replaces the contents of a file with the result of calling update
+// with its current contents, which are nil if the file doesn't exist. Other
+// processes updating the same file wait until this update is finished, and
+// the new contents are renamed into place so that readers never see a
+// partial file.
+func Update(path string, update func(data []byte) ([]byte, error)) (err error) {
+	var unlock func()
+	if unlock, err = lock(path); err != nil {
+		return
+	}
+	defer unlock()
+
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		if !os.IsNotExist(err) {
+			return
+		}
+		data, err = nil, nil
+	}
+
+	if data, err = update(data); err != nil {
+		return
+	}
+	return write(path, data)
+}
+
+// lock creates a lock file next to a file, returning a function that removes
+// it
+func lock(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		var f *os.File
+		if f, err = os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600); err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, &LockedError{Path: path}
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// write writes data to a temporary file and renames it over a file
+func write(path string, data []byte) (err error) {
+	var f *os.File
+	if f, err = ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp"); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Chmod(0600); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(f.Name(), path)
+}
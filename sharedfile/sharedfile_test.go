@@ -0,0 +1,83 @@
+package sharedfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "count")
+
+	// Each update adds one to the count in the file, so any update that's
+	// lost leaves the count short
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(path, func(data []byte) ([]byte, error) {
+				n, _ := strconv.Atoi(string(data))
+				return []byte(strconv.Itoa(n + 1)), nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "20" {
+		t.Errorf("count = %s, want 20", data)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file was left behind: %v", err)
+	}
+}
+
+func TestUpdateStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := ioutil.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Update(path, func(data []byte) ([]byte, error) {
+		if data != nil {
+			t.Errorf("data = %q, want nil for a missing file", data)
+		}
+		return []byte("new"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "new" {
+		t.Errorf("data = %q, want new", data)
+	}
+}
+
+func TestUpdateLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := ioutil.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Update(path, func(data []byte) ([]byte, error) {
+		t.Error("file was updated while locked")
+		return data, nil
+	})
+	if _, ok := err.(*LockedError); !ok {
+		t.Errorf("error = %v, want a LockedError", err)
+	}
+}
@@ -5,35 +5,18 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/fetch"
+	"github.com/jason0x43/alfred-weather/providers"
 )
 
 const (
-	unitsUS     units = "US"
-	unitsMetric units = "Metric"
-
-	serviceDarkSky     = "Dark Sky"
-	serviceOpenWeather = "OpenWeather"
-	serviceClimaCell   = "ClimaCell"
+	serviceDarkSky     = providers.DarkSkyName
+	serviceOpenWeather = providers.OpenWeatherName
+	serviceClimaCell   = providers.ClimaCellName
 )
 
-func round(val float64) int64 {
-	return int64(val + 0.5)
-}
-
-// Location is a named location
-type Location struct {
-	Latitude  float64
-	Longitude float64
-	ShortName string
-	Name      string
-}
-
-// Service is a forecasting service
-type Service interface {
-	About() string
-	Forecast(Location, map[string]string) (Weather, error)
-}
-
 // TimeFormats are the available time formats
 var TimeFormats = []string{
 	"15:04",
@@ -50,13 +33,25 @@ var DateFormats = []string{
 	"2/1/2006",
 }
 
-var client = &http.Client{}
+// client makes requests to weather and geocoding services. The underlying
+// HTTP client's timeout is a backstop for requests made without a deadline.
+var client = fetch.New(&http.Client{Timeout: time.Minute})
+
+// requestTimeout limits how long getting a forecast or looking up a location
+// may take, so that a slow service can't hang the workflow
+const requestTimeout = 20 * time.Second
 
 func getIconFile(name string) string {
-	icon := path.Join("icons", config.Icons, name+".png")
+	return config.iconFile(name)
+}
+
+// iconFile returns the path of a weather icon in the config's icon set. Night
+// icons fall back to the day icon if the set doesn't have one.
+func (c *configStruct) iconFile(name string) string {
+	icon := path.Join("icons", c.Icons, name+".png")
 	if _, err := os.Stat(icon); err != nil {
 		if strings.HasPrefix(name, "nt_") {
-			return path.Join("icons", config.Icons, name[3:]+".png")
+			return path.Join("icons", c.Icons, name[3:]+".png")
 		}
 	}
 	return icon
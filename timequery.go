@@ -0,0 +1,226 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeQuery is a point in time parsed out of a forecast query
+type timeQuery struct {
+	Time    time.Time
+	HasDate bool
+	HasTime bool
+}
+
+// IsSet indicates whether the query contained any time information
+func (t timeQuery) IsSet() bool {
+	return t.HasDate || t.HasTime
+}
+
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+// hours of the day used for named times of day
+var timesOfDay = map[string]int{
+	"morning":   8,
+	"noon":      12,
+	"afternoon": 14,
+	"evening":   18,
+	"tonight":   21,
+	"night":     21,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// weekday abbreviations, some of which are also words that appear in place
+// names, so they're only recognized where they can't be part of a location
+var weekdayAbbreviations = map[string]time.Weekday{
+	"sun":   time.Sunday,
+	"mon":   time.Monday,
+	"tue":   time.Tuesday,
+	"tues":  time.Tuesday,
+	"wed":   time.Wednesday,
+	"thu":   time.Thursday,
+	"thur":  time.Thursday,
+	"thurs": time.Thursday,
+	"fri":   time.Friday,
+	"sat":   time.Saturday,
+}
+
+// words that only have meaning when they precede a time expression
+var timePrepositions = map[string]bool{
+	"at":   true,
+	"on":   true,
+	"this": true,
+	"next": true,
+}
+
+// parseTimeQuery removes date and time expressions like "tomorrow 3pm",
+// "saturday" or "tonight" from a query, returning the remaining location
+// query and the time that was found.
+func parseTimeQuery(query string, now time.Time) (location string, tq timeQuery) {
+	words := strings.Fields(query)
+	used := make([]bool, len(words))
+
+	day := now
+	hour, minute := -1, 0
+	midnight := false
+
+	for i, word := range words {
+		w := strings.ToLower(word)
+		prev := ""
+		if i > 0 {
+			prev = strings.ToLower(words[i-1])
+		}
+
+		switch {
+		case w == "today":
+			tq.HasDate = true
+		case w == "tomorrow" || w == "tmrw":
+			day = now.AddDate(0, 0, 1)
+			tq.HasDate = true
+		case w == "midnight":
+			hour = 0
+			midnight = true
+			tq.HasTime = true
+		case timesOfDay[w] != 0:
+			hour = timesOfDay[w]
+			tq.HasTime = true
+			if w == "tonight" {
+				tq.HasDate = true
+			}
+		case w == "am" || w == "pm":
+			if i == 0 || !used[i-1] || hour == -1 || hour > 12 {
+				continue
+			}
+			hour = to24Hour(hour, w)
+		default:
+			if wd, ok := parseWeekday(words, i); ok {
+				offset := (int(wd) - int(now.Weekday()) + 7) % 7
+				if offset == 0 && prev == "next" {
+					offset = 7
+				}
+				day = now.AddDate(0, 0, offset)
+				tq.HasDate = true
+			} else if m := clockPattern.FindStringSubmatch(w); m != nil && (m[2] != "" || m[3] != "" || isMeridiem(words, i+1)) {
+				h, _ := strconv.Atoi(m[1])
+				if h > 23 {
+					continue
+				}
+				hour = to24Hour(h, m[3])
+				minute, _ = strconv.Atoi(m[2])
+				if minute > 59 {
+					minute = 0
+				}
+				tq.HasTime = true
+			} else {
+				continue
+			}
+		}
+
+		used[i] = true
+		if timePrepositions[prev] {
+			used[i-1] = true
+		}
+	}
+
+	var rest []string
+	for i, word := range words {
+		if !used[i] {
+			rest = append(rest, word)
+		}
+	}
+	if len(rest) > 0 && strings.ToLower(rest[0]) == "in" {
+		rest = rest[1:]
+	}
+	location = strings.Join(rest, " ")
+
+	if !tq.IsSet() {
+		return
+	}
+
+	// Midnight is the end of the day it's given with, wherever the day is in
+	// the query
+	if midnight {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	if hour == -1 {
+		tq.Time = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, now.Location())
+		return
+	}
+
+	tq.Time = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location())
+
+	// A bare time that has already passed today refers to tomorrow
+	if !tq.HasDate && tq.Time.Before(now.Truncate(time.Hour)) {
+		tq.Time = tq.Time.AddDate(0, 0, 1)
+	}
+
+	return
+}
+
+// parseWeekday returns the weekday named by a word of a query. Abbreviations
+// are only accepted after a preposition, like "on sat", or in a time phrase
+// at the end of the query, like "berlin sat 3pm", so that "sun city" is still
+// a location.
+func parseWeekday(words []string, i int) (wd time.Weekday, ok bool) {
+	w := strings.ToLower(words[i])
+	if wd, ok = weekdays[w]; ok {
+		return
+	}
+	if wd, ok = weekdayAbbreviations[w]; !ok {
+		return
+	}
+	if i > 0 && timePrepositions[strings.ToLower(words[i-1])] {
+		return
+	}
+	for _, next := range words[i+1:] {
+		if !isTimeWord(strings.ToLower(next)) {
+			return wd, false
+		}
+	}
+	return
+}
+
+// isTimeWord indicates whether a word can be part of a time expression
+func isTimeWord(w string) bool {
+	switch w {
+	case "today", "tomorrow", "tmrw", "midnight", "am", "pm":
+		return true
+	}
+	_, isDay := weekdays[w]
+	_, isAbbreviation := weekdayAbbreviations[w]
+	return isDay || isAbbreviation || timesOfDay[w] != 0 || timePrepositions[w] || clockPattern.MatchString(w)
+}
+
+func isMeridiem(words []string, i int) bool {
+	if i >= len(words) {
+		return false
+	}
+	w := strings.ToLower(words[i])
+	return w == "am" || w == "pm"
+}
+
+func to24Hour(hour int, meridiem string) int {
+	switch meridiem {
+	case "am":
+		if hour == 12 {
+			return 0
+		}
+	case "pm":
+		if hour < 12 {
+			return hour + 12
+		}
+	}
+	return hour
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeQueryWeekdays(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	saturday := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	sunday := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query    string
+		location string
+		date     time.Time
+	}{
+		{"saturday berlin", "berlin", saturday},
+		{"berlin sat", "berlin", saturday},
+		{"on sat berlin", "berlin", saturday},
+		{"berlin sun 3pm", "berlin", sunday.Add(15 * time.Hour)},
+		{"sun city", "sun city", time.Time{}},
+		{"sun city sat", "sun city", saturday},
+		{"mon repos wed", "mon repos", now.Truncate(24 * time.Hour)},
+		{"sat berlin", "sat berlin", time.Time{}},
+	}
+
+	for _, test := range tests {
+		location, tq := parseTimeQuery(test.query, now)
+		if location != test.location {
+			t.Errorf("%q: location = %q, want %q", test.query, location, test.location)
+		}
+		if !tq.Time.Equal(test.date) {
+			t.Errorf("%q: time = %v, want %v", test.query, tq.Time, test.date)
+		}
+	}
+}
+
+func TestParseTimeQuery(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	today := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+	saturday := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query    string
+		location string
+		time     time.Time
+	}{
+		{"today", "", today},
+		{"tomorrow 3pm", "", tomorrow.Add(15 * time.Hour)},
+		{"3pm tomorrow", "", tomorrow.Add(15 * time.Hour)},
+		{"berlin tomorrow 3 pm", "berlin", tomorrow.Add(15 * time.Hour)},
+		{"tomorrow at 15:30 in berlin", "berlin", tomorrow.Add(15*time.Hour + 30*time.Minute)},
+		{"tonight", "", today.Add(21 * time.Hour)},
+		{"tonight in paris", "paris", today.Add(21 * time.Hour)},
+		{"midnight", "", tomorrow},
+		{"saturday midnight", "", saturday.AddDate(0, 0, 1)},
+		{"midnight saturday", "", saturday.AddDate(0, 0, 1)},
+		{"saturday morning", "", saturday.Add(8 * time.Hour)},
+		{"morning saturday", "", saturday.Add(8 * time.Hour)},
+		{"saturday in berlin", "berlin", saturday},
+		{"in berlin on saturday", "berlin", saturday},
+		// A time that has passed today is tomorrow's
+		{"berlin at 8am", "berlin", tomorrow.Add(8 * time.Hour)},
+		{"berlin", "berlin", time.Time{}},
+	}
+
+	for _, test := range tests {
+		location, tq := parseTimeQuery(test.query, now)
+		if location != test.location {
+			t.Errorf("%q: location = %q, want %q", test.query, location, test.location)
+		}
+		if !tq.Time.Equal(test.time) {
+			t.Errorf("%q: time = %v, want %v", test.query, tq.Time, test.time)
+		}
+	}
+}
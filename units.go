@@ -0,0 +1,163 @@
+package main
+
+import "github.com/jason0x43/alfred-weather/forecast"
+
+// units identifies a preset group of unit settings
+type units string
+
+const (
+	unitsUS     units = "US"
+	unitsMetric units = "Metric"
+	unitsCustom units = "Custom"
+)
+
+// unitConfig is the set of units used to display each measured quantity
+type unitConfig struct {
+	Temperature forecast.TemperatureUnit
+	Wind        forecast.WindUnit
+	Pressure    forecast.PressureUnit
+	Precip      forecast.PrecipUnit
+	Distance    forecast.DistanceUnit
+}
+
+// unitPresets are groups of unit settings that can be applied together
+var unitPresets = map[units]unitConfig{
+	unitsUS: {
+		Temperature: forecast.Fahrenheit,
+		Wind:        forecast.MilesPerHour,
+		Pressure:    forecast.InchesOfMercury,
+		Precip:      forecast.Inches,
+		Distance:    forecast.Miles,
+	},
+	unitsMetric: {
+		Temperature: forecast.Celsius,
+		Wind:        forecast.KilometersPerHour,
+		Pressure:    forecast.Hectopascals,
+		Precip:      forecast.Millimeters,
+		Distance:    forecast.Kilometers,
+	},
+}
+
+// TemperatureUnits are the available temperature units
+var TemperatureUnits = []forecast.TemperatureUnit{forecast.Celsius, forecast.Fahrenheit, forecast.Kelvin}
+
+// WindUnits are the available wind speed units
+var WindUnits = []forecast.WindUnit{forecast.KilometersPerHour, forecast.MetersPerSecond, forecast.MilesPerHour, forecast.Knots, forecast.Beaufort}
+
+// PressureUnits are the available pressure units
+var PressureUnits = []forecast.PressureUnit{forecast.Hectopascals, forecast.InchesOfMercury, forecast.MillimetersOfMercury}
+
+// PrecipUnits are the available precipitation units
+var PrecipUnits = []forecast.PrecipUnit{forecast.Millimeters, forecast.Inches}
+
+// DistanceUnits are the available distance units
+var DistanceUnits = []forecast.DistanceUnit{forecast.Kilometers, forecast.Miles}
+
+// unitChoices returns the available choices for a unit setting, given its
+// config field name
+func unitChoices(field string) (choices []string) {
+	switch field {
+	case "TemperatureUnit":
+		for _, u := range TemperatureUnits {
+			choices = append(choices, string(u))
+		}
+	case "WindUnit":
+		for _, u := range WindUnits {
+			choices = append(choices, string(u))
+		}
+	case "PressureUnit":
+		for _, u := range PressureUnits {
+			choices = append(choices, string(u))
+		}
+	case "PrecipUnit":
+		for _, u := range PrecipUnits {
+			choices = append(choices, string(u))
+		}
+	case "DistanceUnit":
+		for _, u := range DistanceUnits {
+			choices = append(choices, string(u))
+		}
+	}
+	return
+}
+
+// preset returns the name of the preset matching a unit config, or
+// unitsCustom if the config doesn't match any preset
+func (u unitConfig) preset() units {
+	for name, preset := range unitPresets {
+		if preset == u {
+			return name
+		}
+	}
+	return unitsCustom
+}
+
+// withDefaults fills in any unset units from a preset
+func (u unitConfig) withDefaults(preset units) unitConfig {
+	p, ok := unitPresets[preset]
+	if !ok {
+		p = unitPresets[unitsUS]
+	}
+	if u.Temperature == "" {
+		u.Temperature = p.Temperature
+	}
+	if u.Wind == "" {
+		u.Wind = p.Wind
+	}
+	if u.Pressure == "" {
+		u.Pressure = p.Pressure
+	}
+	if u.Precip == "" {
+		u.Precip = p.Precip
+	}
+	if u.Distance == "" {
+		u.Distance = p.Distance
+	}
+	return u
+}
+
+// formatTemperature returns a temperature in the configured units
+func formatTemperature(t forecast.Temperature) string {
+	return config.formatTemperature(t)
+}
+
+// formatSpeed returns a speed in the configured units
+func formatSpeed(s forecast.Speed) string {
+	return config.formatSpeed(s)
+}
+
+// formatPressure returns a pressure in the configured units
+func formatPressure(p forecast.Pressure) string {
+	return config.formatPressure(p)
+}
+
+// formatPrecipitation returns a precipitation amount in the configured units
+func formatPrecipitation(p forecast.Precipitation) string {
+	return config.formatPrecipitation(p)
+}
+
+// formatTemperature returns a temperature in the config's units
+func (c *configStruct) formatTemperature(t forecast.Temperature) string {
+	return t.Format(c.TemperatureUnit, c.temperatureDecimals())
+}
+
+// formatSpeed returns a speed in the config's units
+func (c *configStruct) formatSpeed(s forecast.Speed) string {
+	return s.Format(c.WindUnit)
+}
+
+// formatPressure returns a pressure in the config's units
+func (c *configStruct) formatPressure(p forecast.Pressure) string {
+	return p.Format(c.PressureUnit)
+}
+
+// formatPrecipitation returns a precipitation amount in the config's units
+func (c *configStruct) formatPrecipitation(p forecast.Precipitation) string {
+	return p.Format(c.PrecipUnit)
+}
+
+// roundedTemperature returns a temperature in the config's units, rounded to
+// a whole number
+func (c *configStruct) roundedTemperature(t forecast.Temperature) int64 {
+	return round(t.In(c.TemperatureUnit))
+}
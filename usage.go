@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/fetch"
+	"github.com/jason0x43/alfred-weather/quota"
+	"github.com/jason0x43/go-alfred"
+)
+
+// serviceDailyLimits are the daily request limits of each service's free
+// tier, used as the budget unless another is configured
+var serviceDailyLimits = map[string]int{
+	serviceDarkSky:     1000,
+	serviceOpenWeather: 1000,
+	serviceClimaCell:   1000,
+}
+
+// budgetReserve is the fraction of the daily budget that's held back. No new
+// forecasts are requested once only the reserve is left; a stale cached
+// forecast is shown instead if there is one.
+const budgetReserve = 0.05
+
+// quotas counts the requests made to each service
+var quotas *quota.Tracker
+
+// serviceKey returns the API key for a service
+func serviceKey(service string) string {
+	switch service {
+	case serviceDarkSky:
+		return config.DarkSkyKey
+	case serviceOpenWeather:
+		return config.OpenWeatherKey
+	case serviceClimaCell:
+		return config.ClimaCellKey
+	}
+	return ""
+}
+
+// requestBudget returns the daily request budget for a service. The
+// configured budget applies to the selected service.
+func requestBudget(service string) int {
+	if config.DailyBudget > 0 && service == config.Service {
+		return config.DailyBudget
+	}
+	return serviceDailyLimits[service]
+}
+
+// serviceUsage returns the configured service's usage on the day of a given
+// time
+func serviceUsage(now time.Time) quota.Usage {
+	return quotas.Usage(config.Service, serviceKey(config.Service), now)
+}
+
+// serviceClient returns a client that counts its requests against the quota
+// of a service's API key
+func serviceClient(service, apiKey string) *fetch.Client {
+	c := *client
+	c.OnResponse = func(header http.Header) {
+		quotas.Record(service, apiKey, header, clock.Now())
+	}
+	return &c
+}
+
+// quotaError indicates that the daily request budget is nearly used up
+type quotaError struct {
+	service string
+	left    int
+}
+
+func (e quotaError) Error() string {
+	return fmt.Sprintf("The daily request budget for %s is nearly used up (%d left)", e.service, e.left)
+}
+
+// checkBudget returns a quotaError if fewer than the reserved number of
+// requests are left in the configured service's daily budget
+func checkBudget(now time.Time) error {
+	budget := requestBudget(config.Service)
+	left := serviceUsage(now).Left(budget)
+	if left < 0 {
+		return nil
+	}
+
+	reserve := int(float64(budget) * budgetReserve)
+	if reserve < 1 {
+		reserve = 1
+	}
+	if left < reserve {
+		return quotaError{service: config.Service, left: left}
+	}
+	return nil
+}
+
+// usageItem returns an item describing today's usage of the configured
+// service
+func usageItem() alfred.Item {
+	usage := serviceUsage(clock.Now())
+	budget := requestBudget(config.Service)
+
+	subtitle := fmt.Sprintf("%s, daily budget %d", config.Service, budget)
+	if usage.Remaining >= 0 {
+		subtitle += fmt.Sprintf(", %d left according to %s", usage.Remaining, config.Service)
+	}
+
+	return alfred.Item{
+		Title:        fmt.Sprintf("Usage: %d requests today", usage.Requests),
+		Subtitle:     subtitle,
+		Autocomplete: "DailyBudget ",
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/go-alfred"
+)
+
+// seenAlertsFile is the name of the file, in the data directory, holding the
+// alerts that have already been reported
+const seenAlertsFile = "seen_alerts.json"
+
+// notifier delivers notifications about new alerts
+type notifier interface {
+	Notify(loc forecast.Location, a forecast.Alert) error
+}
+
+// alertNotification is the JSON payload sent to command and webhook notifiers
+type alertNotification struct {
+	Location    string    `json:"location"`
+	Title       string    `json:"title"`
+	Severity    string    `json:"severity"`
+	Onset       time.Time `json:"onset"`
+	Expires     time.Time `json:"expires"`
+	Regions     []string  `json:"regions,omitempty"`
+	Sender      string    `json:"sender,omitempty"`
+	Description string    `json:"description"`
+	URL         string    `json:"url,omitempty"`
+}
+
+func newAlertNotification(loc forecast.Location, a forecast.Alert) alertNotification {
+	return alertNotification{
+		Location:    loc.Name,
+		Title:       a.Title,
+		Severity:    a.Severity.String(),
+		Onset:       a.Onset,
+		Expires:     a.Expires,
+		Regions:     a.Regions,
+		Sender:      a.Sender,
+		Description: a.Description,
+		URL:         a.URL,
+	}
+}
+
+// stdoutNotifier writes alerts to a stream, one per line
+type stdoutNotifier struct {
+	out io.Writer
+}
+
+// Notify writes an alert to the notifier's stream
+func (n stdoutNotifier) Notify(loc forecast.Location, a forecast.Alert) error {
+	_, err := fmt.Fprintf(n.out, "%s [%s] %s: %s (until %s)\n", time.Now().Format(time.RFC3339),
+		a.Severity, loc.Name, a.Title, a.Expires.Format(time.RFC3339))
+	return err
+}
+
+// commandNotifier runs a shell command for each alert. The alert is passed
+// as JSON on stdin, and its main fields are set as environment variables.
+type commandNotifier struct {
+	command string
+}
+
+// Notify runs the notifier's command for an alert
+func (n commandNotifier) Notify(loc forecast.Location, a forecast.Alert) error {
+	data, err := json.Marshal(newAlertNotification(loc, a))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", n.command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"ALERT_LOCATION="+loc.Name,
+		"ALERT_TITLE="+a.Title,
+		"ALERT_SEVERITY="+a.Severity.String(),
+		"ALERT_EXPIRES="+a.Expires.Format(time.RFC3339),
+		"ALERT_URL="+a.URL,
+	)
+	return cmd.Run()
+}
+
+// webhookNotifier POSTs each alert as JSON to a URL
+type webhookNotifier struct {
+	url string
+}
+
+// Notify sends an alert to the notifier's URL
+func (n webhookNotifier) Notify(loc forecast.Location, a forecast.Alert) (err error) {
+	var data []byte
+	if data, err = json.Marshal(newAlertNotification(loc, a)); err != nil {
+		return
+	}
+
+	var request *http.Request
+	if request, err = http.NewRequest("POST", n.url, bytes.NewReader(data)); err != nil {
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", client.UserAgent)
+
+	var resp *http.Response
+	if resp, err = client.HTTP.Do(request); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+
+	return
+}
+
+// newNotifier creates a notifier from a spec: "stdout", "exec:<command>", or
+// an http(s) webhook URL
+func newNotifier(spec string) (notifier, error) {
+	switch {
+	case spec == "stdout":
+		return stdoutNotifier{out: os.Stdout}, nil
+	case strings.HasPrefix(spec, "exec:"):
+		return commandNotifier{command: strings.TrimPrefix(spec, "exec:")}, nil
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return webhookNotifier{url: spec}, nil
+	}
+	return nil, fmt.Errorf("Unknown notifier %q", spec)
+}
+
+// watchNotifier is a notifier along with the spec it was created from, which
+// identifies it in the seen alerts file
+type watchNotifier struct {
+	notifier
+	spec string
+}
+
+// notifierFlags collects repeated -notify flags
+type notifierFlags []string
+
+func (n *notifierFlags) String() string {
+	return strings.Join(*n, ",")
+}
+
+func (n *notifierFlags) Set(value string) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// alertKey returns a key identifying an alert for a location
+func alertKey(loc forecast.Location, a forecast.Alert) string {
+	return fmt.Sprintf("%s|%s|%s|%d", loc.Name, a.Sender, a.Title, a.Onset.Unix())
+}
+
+// runWatch polls for alerts for the default and saved locations, reporting
+// each new alert once. It returns an exit status.
+func runWatch(args []string) int {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := flags.Duration("interval", 15*time.Minute, "time between checks")
+	once := flags.Bool("once", false, "check once and exit")
+	dataDir := flags.String("data", "", "workflow data directory (default: the workflow's data directory)")
+	cacheDir := flags.String("cache", "", "workflow cache directory (default: the workflow's cache directory)")
+	var specs notifierFlags
+	flags.Var(&specs, "notify", `where to send alerts: "stdout", "exec:<command>", or a webhook URL (may be repeated)`)
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *dataDir == "" || *cacheDir == "" {
+		wf, err := alfred.OpenWorkflow(".", true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s (run from the workflow directory, or use -data and -cache)\n", err)
+			return 1
+		}
+		if *dataDir == "" {
+			*dataDir = wf.DataDir()
+		}
+		if *cacheDir == "" {
+			*cacheDir = wf.CacheDir()
+		}
+	}
+
+	initConfig(*dataDir, *cacheDir)
+
+	if err := validateConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	if len(specs) == 0 {
+		specs = append(specs, "stdout")
+	}
+
+	var notifiers []watchNotifier
+	for _, spec := range specs {
+		n, err := newNotifier(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 2
+		}
+		notifiers = append(notifiers, watchNotifier{notifier: n, spec: spec})
+	}
+
+	seenFile := path.Join(*dataDir, seenAlertsFile)
+	seen := map[string]time.Time{}
+	if err := alfred.LoadJSON(seenFile, &seen); err == nil {
+		dlog.Printf("loaded %d seen alerts", len(seen))
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		checkAlerts(seen, notifiers)
+		if err := alfred.SaveJSON(seenFile, &seen); err != nil {
+			dlog.Printf("Unable to save seen alerts: %v", err)
+		}
+
+		if *once {
+			return 0
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			dlog.Printf("stopping")
+			return 0
+		}
+	}
+}
+
+// checkAlerts reports any active alerts and rule matches that haven't been
+// seen yet, and forgets ones that have expired
+func checkAlerts(seen map[string]time.Time, notifiers []watchNotifier) {
+	now := clock.Now()
+	rules := loadRules()
+
+	for key, expires := range seen {
+		if expires.Before(now) {
+			delete(seen, key)
+		}
+	}
+
+	for _, loc := range allLocations() {
+		weather, err := getForecast(loc, now)
+		if err != nil {
+			dlog.Printf("Unable to get forecast for %s: %v", loc.Name, err)
+			continue
+		}
+
+		for _, a := range weather.Alerts {
+			if a.Expires.After(now) {
+				notifyAll(seen, alertKey(loc, a), a.Expires, loc, a, notifiers)
+			}
+		}
+
+		for _, m := range evaluateRules(rules, &weather, now) {
+			key := fmt.Sprintf("rule|%s|%s|%s", loc.Name, m.Rule, m.Time.Format("2006-01-02"))
+			notifyAll(seen, key, m.Until, loc, m.alert(), notifiers)
+		}
+	}
+}
+
+// notifyAll sends an alert to each notifier that hasn't delivered it yet.
+// Deliveries are recorded per notifier, so a notifier that fails is retried
+// on the next check without repeating the alert on the others.
+func notifyAll(seen map[string]time.Time, key string, until time.Time, loc forecast.Location, a forecast.Alert, notifiers []watchNotifier) {
+	for _, n := range notifiers {
+		k := key + "|" + n.spec
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		if err := n.Notify(loc, a); err != nil {
+			dlog.Printf("Unable to send notification for %s to %s: %v", a.Title, n.spec, err)
+			continue
+		}
+		seen[k] = until
+	}
+}
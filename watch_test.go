@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+)
+
+// fakeNotifier counts notifications, failing while err is set
+type fakeNotifier struct {
+	sent int
+	err  error
+}
+
+func (n *fakeNotifier) Notify(loc forecast.Location, a forecast.Alert) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent++
+	return nil
+}
+
+func TestNotifyAllRetriesFailedNotifiers(t *testing.T) {
+	ok := &fakeNotifier{}
+	failing := &fakeNotifier{err: errors.New("webhook returned 503 Service Unavailable")}
+	notifiers := []watchNotifier{{notifier: ok, spec: "stdout"}, {notifier: failing, spec: "https://example.com/hook"}}
+
+	seen := map[string]time.Time{}
+	loc := forecast.Location{Name: "Home"}
+	a := forecast.Alert{Title: "Storm Warning"}
+	until := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	notifyAll(seen, "Home|Storm Warning", until, loc, a, notifiers)
+	if ok.sent != 1 || failing.sent != 0 {
+		t.Fatalf("first check: sent %d and %d, want 1 and 0", ok.sent, failing.sent)
+	}
+
+	failing.err = nil
+	notifyAll(seen, "Home|Storm Warning", until, loc, a, notifiers)
+	if ok.sent != 1 || failing.sent != 1 {
+		t.Fatalf("second check: sent %d and %d, want 1 and 1", ok.sent, failing.sent)
+	}
+
+	notifyAll(seen, "Home|Storm Warning", until, loc, a, notifiers)
+	if ok.sent != 1 || failing.sent != 1 {
+		t.Fatalf("third check: sent %d and %d, want 1 and 1", ok.sent, failing.sent)
+	}
+}
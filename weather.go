@@ -1,141 +1,223 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
 	"time"
 
-	"github.com/jason0x43/go-alfred"
+	"github.com/jason0x43/alfred-weather/cache"
+	"github.com/jason0x43/alfred-weather/fetch"
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/alfred-weather/geocoding"
+	"github.com/jason0x43/alfred-weather/providers"
 )
 
-// Alert is a weather alert (e.g., severe thunderstorm)
-type alert struct {
-	Description string    `json:"description"`
-	Expires     time.Time `json:"expires"`
-	URL         string    `json:"url"`
+func getWeather(query string, now time.Time) (loc forecast.Location, weather forecast.Weather, err error) {
+	if err = validateConfig(); err != nil {
+		return
+	}
+
+	if coords, ok := parseCoordinates(query); ok {
+		return weatherAt(coords, now)
+	}
+
+	if query != "" {
+		if loc, err = locate(query); err != nil {
+			return
+		}
+		dlog.Printf("got location")
+	} else {
+		loc = config.Location
+		dlog.Printf("using configured location")
+	}
+
+	weather, err = getForecast(loc, now)
+	return
+}
+
+// locationNotFoundError indicates that a location query had no matches
+type locationNotFoundError string
+
+func (e locationNotFoundError) Error() string {
+	return fmt.Sprintf("No location found for %q", string(e))
 }
 
-// DailyForecast represents future weather conditions
-type dailyForecast struct {
-	Date     time.Time
-	Summary  string
-	Icon     string
-	HighTemp temperature
-	LowTemp  temperature
-	Sunrise  time.Time
-	Sunset   time.Time
-	Precip   int
+// locateGeocodes looks up a location with the default geocoder. It's a
+// variable so that lookups can be directed somewhere else.
+var locateGeocodes = func(location string) ([]geocoding.Geocode, error) {
+	dlog.Printf("Locating %s", location)
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	return geocoding.NewGeocoder(client).Locate(ctx, location)
 }
 
-// HourlyForecast represents future weather conditions
-type hourlyForecast struct {
-	Time         time.Time
-	Summary      string
-	Icon         string
-	Temp         temperature
-	ApparentTemp temperature
-	Precip       int
+// locate returns the best match for a location query
+func locate(query string) (loc forecast.Location, err error) {
+	var geos []geocoding.Geocode
+	if geos, err = locateGeocodes(query); err != nil {
+		return
+	}
+	if len(geos) == 0 {
+		return loc, locationNotFoundError(query)
+	}
+	return geos[0].Location(), nil
 }
 
-// Int64 returns the value of the temperature in the currently configured units
-// as an int64. Temperatures are assumed to be in Celsius by default
-func (t temperature) Int64() int64 {
-	if config.Units == unitsMetric {
-		return round(float64(t))
+// coordinatePattern matches a "latitude,longitude" query
+var coordinatePattern = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s*,\s*(-?\d+(?:\.\d+)?)\s*$`)
+
+// parseCoordinates returns a location for a "latitude,longitude" query
+func parseCoordinates(query string) (loc forecast.Location, ok bool) {
+	m := coordinatePattern.FindStringSubmatch(query)
+	if m == nil {
+		return
 	}
-	return round(float64(t)*(9.0/5.0) + 32.0)
+	loc.Latitude, _ = strconv.ParseFloat(m[1], 64)
+	loc.Longitude, _ = strconv.ParseFloat(m[2], 64)
+	if math.Abs(loc.Latitude) > 90 || math.Abs(loc.Longitude) > 180 {
+		return loc, false
+	}
+	loc.Name = fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude)
+	loc.ShortName = loc.Name
+	return loc, true
 }
 
-// temperature is a temperature in degrees Celsius
-type temperature float64
-
-// units identifies US or Metric units
-type units string
-
-// Weather is weather information
-type Weather struct {
-	Current struct {
-		Summary      string
-		Icon         string
-		Humidity     float64
-		Temp         temperature
-		ApparentTemp temperature
-		Time         time.Time
-	}
-	Daily  []dailyForecast
-	Hourly []hourlyForecast
-	Alerts []alert
-	URL    string
+// reverseGeocode looks up the place at a pair of coordinates with the default
+// geocoder. It's a variable so that lookups can be directed somewhere else.
+var reverseGeocode = func(ctx context.Context, latitude, longitude float64) (geocoding.Geocode, bool, error) {
+	return geocoding.NewGeocoder(client).Reverse(ctx, latitude, longitude)
 }
 
-// IsAtNight indicates whether a given time is at night
-func (w *Weather) IsAtNight(t time.Time) bool {
-	for i := 0; i < len(w.Daily)-1; i++ {
-		if t.After(w.Daily[i].Sunset) && t.Before(w.Daily[i+1].Sunrise) {
-			return true
+// weatherAt returns the forecast for a pair of coordinates. The place is
+// named by a reverse lookup made at the same time as the forecast request;
+// the coordinates are used as the name if the lookup fails.
+func weatherAt(coords forecast.Location, now time.Time) (loc forecast.Location, weather forecast.Weather, err error) {
+	loc = coords
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	err = fetch.All(ctx,
+		func(ctx context.Context) (err error) {
+			weather, err = fetchForecast(ctx, coords, now)
+			return
+		},
+		func(ctx context.Context) error {
+			geo, found, err := reverseGeocode(ctx, coords.Latitude, coords.Longitude)
+			if err != nil {
+				dlog.Printf("Unable to name %s: %v", coords.Name, err)
+			} else if found {
+				loc.Name = geo.Name
+				loc.ShortName = geo.Name
+			}
+			return nil
+		},
+	)
+	return
+}
+
+// getForecast returns the weather for a location at a given time. Weather for
+// the default and saved locations is cached.
+func getForecast(loc forecast.Location, now time.Time) (weather forecast.Weather, err error) {
+	cacheable := loc.Name == config.Location.Name || isSavedLocation(loc)
+
+	var entry cache.Entry
+	var cached bool
+	if cacheable {
+		if entry, cached = weatherCache.Get(loc.Name); cached && !entry.Expired(now, config.Service) {
+			dlog.Printf("Using cached weather for %s", loc.Name)
+			return entry.Weather, nil
 		}
 	}
-	return false
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if weather, err = fetchForecast(ctx, loc, now); err != nil {
+		// Stale weather is better than none when requests are being refused
+		if isQuotaError(err) && cached && entry.Service == config.Service {
+			dlog.Printf("Using stale weather for %s: %v", loc.Name, err)
+			return entry.Weather, nil
+		}
+		return
+	}
+
+	if cacheable {
+		weatherCache.Put(loc.Name, cache.Entry{Weather: weather, Time: now, Service: config.Service})
+		if err := weatherCache.Save(); err != nil {
+			dlog.Printf("Unable to save cache: %v", err)
+		}
+	}
+
+	return
 }
 
-func getWeather(query string) (loc Location, weather Weather, err error) {
-	if err = validateConfig(); err != nil {
+// fetchForecast gets the weather for a location from the configured service,
+// unless its daily budget is nearly used up
+func fetchForecast(ctx context.Context, loc forecast.Location, now time.Time) (weather forecast.Weather, err error) {
+	if err = checkBudget(now); err != nil {
 		return
 	}
 
-	expired := time.Now().Sub(cache.Time).Minutes() >= 5.0 ||
-		time.Now().Format("1/2/2016") != cache.Time.Format("1/2/2016") ||
-		cache.Service != config.Service
+	var service forecast.Provider
+	if service, err = newService(); err != nil {
+		return
+	}
 
-	if query == "" && !expired {
-		dlog.Printf("Using cached weather")
-		return config.Location, cache.Weather, nil
+	weather, err = service.Forecast(ctx, loc)
+	if err := quotas.Save(); err != nil {
+		dlog.Printf("Unable to save usage: %v", err)
+	}
+	if err != nil {
+		return
 	}
 
-	if query != "" {
-		var geos []Geocode
-		if geos, err = Locate(query); err != nil {
-			return
-		}
-		geo := geos[0]
-		loc = geo.Location()
-		dlog.Printf("got location")
-	} else {
-		loc = config.Location
-		dlog.Printf("using configured location")
+	forecast.SortAlerts(weather.Alerts)
+	return
+}
+
+// newService returns a handle for the configured forecast service. It's a
+// variable so that forecasts can be fetched from somewhere else.
+var newService = func() (forecast.Provider, error) {
+	return newProvider(config.Service, serviceKey(config.Service))
+}
+
+// newProvider returns a handle for a service that uses an API key. It's a
+// variable so that services can be replaced.
+var newProvider = func(service, apiKey string) (forecast.Provider, error) {
+	options := providers.Options{APIKey: apiKey, Language: config.Language, Client: serviceClient(service, apiKey)}
+	switch service {
+	case serviceDarkSky:
+		return providers.NewDarkSky(options), nil
+	case serviceOpenWeather:
+		return providers.NewOpenWeather(options), nil
+	case serviceClimaCell:
+		return providers.NewClimaCell(options), nil
 	}
+	return nil, fmt.Errorf("Unknown service %q", service)
+}
 
-	if loc.Name != config.Location.Name || expired {
-		switch config.Service {
-		case serviceDarkSky:
-			service := NewDarkSky(config.DarkSkyKey)
-			if weather, err = service.Forecast(loc); err != nil {
-				return
-			}
-		case serviceOpenWeather:
-			service := NewOpenWeather(config.OpenWeatherKey)
-			if weather, err = service.Forecast(loc); err != nil {
-				return
-			}
-		case serviceClimaCell:
-			service := NewClimaCell(config.ClimaCellKey)
-			if weather, err = service.Forecast(loc); err != nil {
-				return
-			}
+// isSavedLocation indicates whether a location is one of the saved locations
+func isSavedLocation(loc forecast.Location) bool {
+	for _, l := range config.SavedLocations {
+		if l.Name == loc.Name {
+			return true
 		}
+	}
+	return false
+}
 
-		if loc.Name == config.Location.Name {
-			cache.Service = config.Service
-			cache.Time = time.Now()
-			cache.Weather = weather
-			if err := alfred.SaveJSON(cacheFile, &cache); err != nil {
-				dlog.Printf("Unable to save cache: %v", err)
-			}
+// allLocations returns the default location followed by any saved locations
+func allLocations() []forecast.Location {
+	locations := []forecast.Location{config.Location}
+	for _, l := range config.SavedLocations {
+		if l.Name != config.Location.Name {
+			locations = append(locations, l)
 		}
-	} else {
-		weather = cache.Weather
 	}
-
-	return
+	return locations
 }
 
 func validateConfig() error {
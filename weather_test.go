@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/cache"
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/alfred-weather/geocoding"
+	"github.com/jason0x43/alfred-weather/quota"
+)
+
+// testNow is the time the getWeather tests run at
+var testNow = time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+// fakeProvider is a forecast service that returns a fixed forecast and
+// records the locations it was asked about
+type fakeProvider struct {
+	weather forecast.Weather
+	err     error
+	calls   []forecast.Location
+}
+
+func (p *fakeProvider) Forecast(ctx context.Context, loc forecast.Location) (forecast.Weather, error) {
+	p.calls = append(p.calls, loc)
+	return p.weather, p.err
+}
+
+// useFakeServices points the geocoder and forecast hooks at fakes and gives
+// the config, cache and quota tracker test values. Everything is restored
+// when the test ends. Lookups fail unless a test replaces the geocoder hooks.
+func useFakeServices(t *testing.T) *fakeProvider {
+	savedConfig, savedCache, savedQuotas := config, weatherCache, quotas
+	savedLocate, savedReverse, savedService := locateGeocodes, reverseGeocode, newService
+	t.Cleanup(func() {
+		config, weatherCache, quotas = savedConfig, savedCache, savedQuotas
+		locateGeocodes, reverseGeocode, newService = savedLocate, savedReverse, savedService
+	})
+
+	dir := t.TempDir()
+	var err error
+	if weatherCache, err = cache.Open(filepath.Join(dir, "cache.json")); err != nil {
+		t.Fatal(err)
+	}
+	if quotas, err = quota.Open(filepath.Join(dir, "usage.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	config = configStruct{
+		Service:    serviceDarkSky,
+		DarkSkyKey: "key",
+		Location:   forecast.Location{Name: "Berlin", ShortName: "Berlin", Latitude: 52.52, Longitude: 13.405},
+	}
+
+	provider := &fakeProvider{weather: testWeather()}
+	newService = func() (forecast.Provider, error) {
+		return provider, nil
+	}
+	locateGeocodes = func(location string) ([]geocoding.Geocode, error) {
+		return nil, errors.New("unexpected lookup of " + location)
+	}
+	reverseGeocode = func(ctx context.Context, latitude, longitude float64) (geocoding.Geocode, bool, error) {
+		return geocoding.Geocode{}, false, errors.New("unexpected reverse lookup")
+	}
+
+	return provider
+}
+
+func TestGetWeatherQuery(t *testing.T) {
+	provider := useFakeServices(t)
+	locateGeocodes = func(location string) ([]geocoding.Geocode, error) {
+		return []geocoding.Geocode{
+			{Name: "Paris, France", Latitude: 48.857, Longitude: 2.352},
+			{Name: "Paris, Texas", Latitude: 33.661, Longitude: -95.556},
+		}, nil
+	}
+
+	loc, weather, err := getWeather("paris", testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Name != "Paris, France" {
+		t.Errorf("location = %q, want the first match", loc.Name)
+	}
+	if len(provider.calls) != 1 || provider.calls[0].Latitude != 48.857 {
+		t.Errorf("provider calls = %v, want one for the first match", provider.calls)
+	}
+	if weather.Current.Summary != provider.weather.Current.Summary {
+		t.Errorf("weather = %q, want the provider's", weather.Current.Summary)
+	}
+}
+
+func TestGetWeatherNotFound(t *testing.T) {
+	provider := useFakeServices(t)
+	locateGeocodes = func(location string) ([]geocoding.Geocode, error) {
+		return nil, nil
+	}
+
+	_, _, err := getWeather("nowhere", testNow)
+	var notFound locationNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("error = %v, want a locationNotFoundError", err)
+	}
+	if len(provider.calls) != 0 {
+		t.Error("provider was called for an unknown location")
+	}
+}
+
+func TestGetWeatherCoordinates(t *testing.T) {
+	provider := useFakeServices(t)
+	reverseGeocode = func(ctx context.Context, latitude, longitude float64) (geocoding.Geocode, bool, error) {
+		return geocoding.Geocode{Name: "Mitte, Berlin", Latitude: latitude, Longitude: longitude}, true, nil
+	}
+
+	loc, _, err := getWeather("52.5,13.4", testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Name != "Mitte, Berlin" || loc.Latitude != 52.5 || loc.Longitude != 13.4 {
+		t.Errorf("location = %+v, want Mitte, Berlin at the query's coordinates", loc)
+	}
+	if len(provider.calls) != 1 {
+		t.Errorf("provider was called %d times, want 1", len(provider.calls))
+	}
+}
+
+func TestGetWeatherCoordinatesUnnamed(t *testing.T) {
+	useFakeServices(t)
+
+	loc, _, err := getWeather("52.5,13.4", testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Name != "52.5000, 13.4000" {
+		t.Errorf("location = %q, want the coordinates when the reverse lookup fails", loc.Name)
+	}
+}
+
+func TestGetWeatherCachesDefaultLocation(t *testing.T) {
+	provider := useFakeServices(t)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := getWeather("", testNow); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(provider.calls) != 1 {
+		t.Errorf("provider was called %d times, want 1", len(provider.calls))
+	}
+
+	// Once the cached forecast expires, the provider is asked again
+	if _, _, err := getWeather("", testNow.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.calls) != 2 {
+		t.Errorf("provider was called %d times after expiry, want 2", len(provider.calls))
+	}
+}
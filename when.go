@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/go-alfred"
+)
+
+// WhenCommand answers questions like "when will it rain?"
+type WhenCommand struct{}
+
+// About returns information about a command
+func (c WhenCommand) About() alfred.CommandDef {
+	return alfred.CommandDef{
+		Keyword:     "when",
+		Description: "Find when it will next rain, snow, clear up, or reach a temperature",
+		IsEnabled:   true,
+	}
+}
+
+// Items returns the items for the command
+func (c WhenCommand) Items(arg, data string) (items []alfred.Item, err error) {
+	dlog.Printf("Running WhenCommand")
+
+	q, query, ok := parseWhenQuery(&config, arg)
+	if !ok {
+		for _, name := range whenQuestions {
+			if alfred.FuzzyMatches(name, arg) {
+				items = append(items, alfred.Item{
+					Title:        "When: " + name,
+					Autocomplete: name + " ",
+					Subtitle:     tr("Add a location, or leave blank for the default location"),
+				})
+			}
+		}
+		return
+	}
+
+	now := clock.Now()
+
+	var weather forecast.Weather
+	var loc forecast.Location
+	if loc, weather, err = getWeather(query, now); err != nil {
+		return []alfred.Item{errorItem(err)}, nil
+	}
+
+	return whenItems(&config, loc, weather, q, now), nil
+}
+
+// whenItems returns items answering a when question with a forecast at a
+// given time. Text is formatted according to c, which should be the config
+// the question was parsed with.
+func whenItems(c *configStruct, loc forecast.Location, weather forecast.Weather, q whenQuery, now time.Time) (items []alfred.Item) {
+	items = append(items, alfred.Item{
+		Title:    c.tr("Weather for %s", loc.Name),
+		Subtitle: alfred.Line,
+		Arg: &alfred.ItemArg{
+			Keyword: "daily",
+		},
+	})
+
+	if start, end := findHourlyWindow(weather, q); start != -1 {
+		first := weather.Hourly[start]
+		last := weather.Hourly[end]
+		until := last.Time.Add(time.Hour)
+
+		title := fmt.Sprintf("%s: %s %s–%s", q.label, c.relativeDay(first.Time, now),
+			first.Time.Format(c.TimeFormat), until.Format(c.TimeFormat))
+		if detail := q.detail(weather.Hourly[start : end+1]); detail != "" {
+			title += " (" + detail + ")"
+		}
+
+		items = append(items, alfred.Item{
+			Title:    title,
+			Subtitle: c.tr("%d hour(s)", end-start+1),
+			Icon:     c.iconFile(first.Icon),
+			Arg: &alfred.ItemArg{
+				Keyword: "hourly",
+				Data:    alfred.Stringify(&hourlyConfig{Start: &first.Time}),
+			},
+		})
+
+		for _, entry := range weather.Hourly[start : end+1] {
+			items = append(items, alfred.Item{
+				Title:    c.shortWeekday(entry.Time) + " " + entry.Time.Format(c.TimeFormat) + ": " + entry.Summary,
+				Subtitle: hourlySubtitle(c, entry),
+				Icon:     c.iconFile(entry.Icon),
+			})
+		}
+
+		return
+	}
+
+	if i := findDaily(weather, q); i != -1 {
+		entry := weather.Daily[i]
+		title := fmt.Sprintf("%s: %s", q.label, c.relativeDay(entry.Date, now))
+		if entry.Precip != -1 && q.precip {
+			title += fmt.Sprintf(" (%d%%)", entry.Precip)
+		}
+
+		item := alfred.Item{
+			Title:    title,
+			Subtitle: fmt.Sprintf("%s    ↓ %s    ↑ %s", entry.Summary, c.formatTemperature(entry.LowTemp), c.formatTemperature(entry.HighTemp)),
+			Icon:     c.iconFile(entry.Icon),
+		}
+
+		if hasHourly(weather, entry.Date) {
+			item.Arg = &alfred.ItemArg{
+				Keyword: "hourly",
+				Data:    alfred.Stringify(&hourlyConfig{Start: &entry.Sunrise}),
+			}
+		}
+
+		items = append(items, item)
+		return
+	}
+
+	items = append(items, alfred.Item{
+		Title:    c.tr("No %s in the forecast", q.name),
+		Subtitle: c.tr("Nothing matched in the next %d days", len(weather.Daily)),
+	})
+
+	return
+}
+
+// whenQuestions are the question keywords understood by the when command
+var whenQuestions = []string{
+	"rain",
+	"snow",
+	"dry",
+	"sun",
+	"below",
+	"above",
+}
+
+var rainIcons = map[string]bool{
+	"rain":          true,
+	"chancerain":    true,
+	"tstorms":       true,
+	"chancetstorms": true,
+	"sleet":         true,
+	"chancesleet":   true,
+}
+
+var snowIcons = map[string]bool{
+	"snow":           true,
+	"chancesnow":     true,
+	"flurries":       true,
+	"chanceflurries": true,
+	"sleet":          true,
+	"chancesleet":    true,
+}
+
+var sunIcons = map[string]bool{
+	"clear":        true,
+	"sunny":        true,
+	"mostlysunny":  true,
+	"partlysunny":  true,
+	"partlycloudy": true,
+}
+
+// whenQuery is a parsed question for the when command
+type whenQuery struct {
+	name   string
+	label  string
+	precip bool
+
+	hourly func(w *forecast.Weather, h forecast.Hourly) bool
+	daily  func(d forecast.Daily) bool
+	detail func(h []forecast.Hourly) string
+}
+
+// parseWhenQuery splits a query like "below 0 berlin" into a question and a
+// location query. Temperatures and labels are in c's units and language.
+func parseWhenQuery(c *configStruct, arg string) (q whenQuery, location string, ok bool) {
+	words := strings.Fields(arg)
+	if len(words) == 0 {
+		return
+	}
+
+	keyword := strings.ToLower(words[0])
+	rest := words[1:]
+
+	switch keyword {
+	case "rain", "snow":
+		icons := rainIcons
+		if keyword == "snow" {
+			icons = snowIcons
+		}
+		q = whenQuery{
+			name:   c.tr(keyword),
+			label:  c.tr("Next " + keyword),
+			precip: true,
+			hourly: func(w *forecast.Weather, h forecast.Hourly) bool {
+				return icons[strings.TrimPrefix(h.Icon, "nt_")]
+			},
+			daily: func(d forecast.Daily) bool {
+				return icons[d.Icon] && (d.Precip == -1 || d.Precip >= 30)
+			},
+			detail: maxPrecipDetail,
+		}
+
+	case "dry":
+		q = whenQuery{
+			name:   c.tr("dry weather"),
+			label:  c.tr("Dry"),
+			precip: true,
+			hourly: func(w *forecast.Weather, h forecast.Hourly) bool {
+				icon := strings.TrimPrefix(h.Icon, "nt_")
+				return !rainIcons[icon] && !snowIcons[icon] && h.Precip < 30
+			},
+			daily: func(d forecast.Daily) bool {
+				return !rainIcons[d.Icon] && !snowIcons[d.Icon] && d.Precip < 30
+			},
+			detail: maxPrecipDetail,
+		}
+
+	case "sun":
+		q = whenQuery{
+			name:  c.tr("sun"),
+			label: c.tr("Sunny"),
+			hourly: func(w *forecast.Weather, h forecast.Hourly) bool {
+				return sunIcons[h.Icon] && !w.IsAtNight(h.Time)
+			},
+			daily: func(d forecast.Daily) bool {
+				return sunIcons[d.Icon]
+			},
+			detail: func(h []forecast.Hourly) string { return "" },
+		}
+
+	case "below", "above":
+		if len(rest) == 0 {
+			return
+		}
+		limit, err := strconv.ParseInt(strings.TrimRight(rest[0], "°CFcf"), 10, 64)
+		if err != nil {
+			return
+		}
+		rest = rest[1:]
+
+		below := keyword == "below"
+		matches := func(t forecast.Temperature) bool {
+			if below {
+				return c.roundedTemperature(t) < limit
+			}
+			return c.roundedTemperature(t) > limit
+		}
+
+		q = whenQuery{
+			name:  c.tr("temperatures "+keyword+" %d°", limit),
+			label: c.tr(strings.ToUpper(keyword[:1])+keyword[1:]+" %d°", limit),
+			hourly: func(w *forecast.Weather, h forecast.Hourly) bool {
+				return matches(h.Temp)
+			},
+			daily: func(d forecast.Daily) bool {
+				if below {
+					return matches(d.LowTemp)
+				}
+				return matches(d.HighTemp)
+			},
+			detail: func(h []forecast.Hourly) string {
+				extreme := h[0].Temp
+				for _, entry := range h[1:] {
+					if (below && entry.Temp < extreme) || (!below && entry.Temp > extreme) {
+						extreme = entry.Temp
+					}
+				}
+				return c.formatTemperature(extreme)
+			},
+		}
+
+	default:
+		return
+	}
+
+	return q, strings.Join(rest, " "), true
+}
+
+// findHourlyWindow returns the indices of the first and last entries of the
+// first run of hourly forecasts matching a query, or -1 if none match.
+func findHourlyWindow(weather forecast.Weather, q whenQuery) (start, end int) {
+	start = -1
+	for i, entry := range weather.Hourly {
+		if q.hourly(&weather, entry) {
+			if start == -1 {
+				start = i
+			}
+			end = i
+		} else if start != -1 {
+			break
+		}
+	}
+	return
+}
+
+// findDaily returns the index of the first daily forecast matching a query, or
+// -1 if none match.
+func findDaily(weather forecast.Weather, q whenQuery) int {
+	for i, entry := range weather.Daily {
+		if q.daily(entry) {
+			return i
+		}
+	}
+	return -1
+}
+
+// maxPrecipDetail returns the highest chance of precipitation in a run of
+// hourly forecasts, or nothing if none of the chances are known
+func maxPrecipDetail(h []forecast.Hourly) string {
+	max := -1
+	for _, entry := range h {
+		if entry.Precip > max {
+			max = entry.Precip
+		}
+	}
+	if max == -1 {
+		return ""
+	}
+	return fmt.Sprintf("%d%%", max)
+}
+
+// relativeDay returns "Today", "Tomorrow", or a weekday name for a time in
+// the config's language
+func (c *configStruct) relativeDay(t, now time.Time) string {
+	switch t.Format("1/2/2006") {
+	case now.Format("1/2/2006"):
+		return c.tr("Today")
+	case now.AddDate(0, 0, 1).Format("1/2/2006"):
+		return c.tr("Tomorrow")
+	}
+	return c.weekday(t)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jason0x43/alfred-weather/forecast"
+	"github.com/jason0x43/go-alfred"
+)
+
+// whenWeather returns a forecast for two days with a few hours of sun, rain
+// and snow on the first
+func whenWeather(day time.Time) (weather forecast.Weather) {
+	weather.Daily = []forecast.Daily{
+		{Date: day, Summary: "Rain and snow", Icon: "rain", HighTemp: 3, LowTemp: -2, Precip: -1,
+			Sunrise: day.Add(7 * time.Hour), Sunset: day.Add(18 * time.Hour)},
+		{Date: day.AddDate(0, 0, 1), Summary: "Clear", Icon: "clear", HighTemp: 8, LowTemp: 1, Precip: 10,
+			Sunrise: day.Add(31 * time.Hour), Sunset: day.Add(42 * time.Hour)},
+	}
+
+	hours := []struct {
+		icon   string
+		temp   forecast.Temperature
+		precip int
+	}{
+		{"clear", 2, -1},
+		{"cloudy", 3, -1},
+		{"rain", 2, -1},
+		{"rain", 1.5, -1},
+		{"cloudy", 0.2, 20},
+		{"snow", -1.5, 60},
+		{"snow", -2, 40},
+	}
+	for i, h := range hours {
+		weather.Hourly = append(weather.Hourly, forecast.Hourly{
+			Time:    day.Add(time.Duration(10+i) * time.Hour),
+			Summary: h.icon,
+			Icon:    h.icon,
+			Temp:    h.temp,
+			Precip:  h.precip,
+		})
+	}
+	return
+}
+
+func TestWhenItems(t *testing.T) {
+	useOtherConfig(t)
+
+	loc := forecast.Location{Name: "Berlin, Germany", ShortName: "Berlin"}
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	now := day.Add(10 * time.Hour)
+	weather := whenWeather(day)
+
+	for _, c := range itemConfigs {
+		name := "when_" + c.name + ".golden"
+		t.Run(name, func(t *testing.T) {
+			var items []alfred.Item
+			for _, question := range []string{"rain", "snow", "dry", "sun", "below 0", "above 5", "above 30"} {
+				q, _, ok := parseWhenQuery(&c.config, question)
+				if !ok {
+					t.Fatalf("%q wasn't understood", question)
+				}
+				items = append(items, whenItems(&c.config, loc, weather, q, now)...)
+			}
+			checkGoldenItems(t, name, items)
+		})
+	}
+}
+
+func TestMaxPrecipDetail(t *testing.T) {
+	tests := []struct {
+		chances []int
+		want    string
+	}{
+		{[]int{-1, -1}, ""},
+		{[]int{-1, 0}, "0%"},
+		{[]int{20, -1, 60}, "60%"},
+	}
+
+	for _, test := range tests {
+		var hours []forecast.Hourly
+		for _, p := range test.chances {
+			hours = append(hours, forecast.Hourly{Precip: p})
+		}
+		if got := maxPrecipDetail(hours); got != test.want {
+			t.Errorf("maxPrecipDetail(%v) = %q, want %q", test.chances, got, test.want)
+		}
+	}
+}